@@ -1,137 +1,276 @@
 package calendar
 
 import (
+	"bufio"
 	"context"
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/calendar/v3"
 )
 
-// Retrieve a token, saves the token, then returns the generated client.
-func getClient(config *oauth2.Config) *http.Client {
-	// The file token.json stores the user's access and refresh tokens and is
-	// created automatically when the authorization flow completes for the first
-	// time.
-	tokFile := "token.json"
-	tok, err := tokenFromFile(tokFile)
+// ErrNotAuthorized means no Google token has been saved yet.
+var ErrNotAuthorized = errors.New("google calendar is not authorized, run `obsidian_planner auth google`")
+
+const (
+	// legacyTokenFile is where tokens were kept in the working directory
+	// before they moved to a TokenStore. It is imported and removed.
+	legacyTokenFile = "token.json"
+	// authTimeout bounds how long the loopback flow waits for the browser.
+	authTimeout = 2 * time.Minute
+)
+
+// AuthFlow asks the user to grant the planner access to their calendar.
+type AuthFlow interface {
+	Token(ctx context.Context, config *oauth2.Config) (*oauth2.Token, error)
+}
+
+// GoogleAuth locates the OAuth client and where the user's token is kept.
+type GoogleAuth struct {
+	// Credentials is the OAuth client file downloaded from the Google Cloud
+	// console.
+	Credentials string
+	Tokens      TokenStore
+}
+
+// Authorize runs flow and saves the token for New to use.
+func (a GoogleAuth) Authorize(ctx context.Context, flow AuthFlow) error {
+	config, err := a.config()
+	if err != nil {
+		return err
+	}
+	tok, err := flow.Token(ctx, config)
 	if err != nil {
-		tok = getTokenFromWeb(config)
-		saveToken(tokFile, tok)
+		return err
 	}
-	return config.Client(context.Background(), tok)
+	return a.Tokens.Save(tok)
 }
 
-// Request a token from the web, then returns the retrieved token.
-func getTokenFromWeb(config *oauth2.Config) *oauth2.Token {
-	// Generate a random state for security
-	b := make([]byte, 32)
-	if _, err := rand.Read(b); err != nil {
-		log.Fatalf("Unable to generate random state: %v", err)
+// config reads the OAuth client, asking for read-only calendar access.
+// If modifying the scopes, authorize again.
+func (a GoogleAuth) config() (*oauth2.Config, error) {
+	b, err := os.ReadFile(a.Credentials)
+	if err != nil {
+		return nil, fmt.Errorf("reading Google credentials: %w", err)
+	}
+	return google.ConfigFromJSON(b, calendar.CalendarReadonlyScope)
+}
+
+// client returns a client using the saved token. Each refreshed token is
+// saved back, so the next run starts from it.
+func (a GoogleAuth) client(ctx context.Context) (*http.Client, *persistingTokenSource, error) {
+	config, err := a.config()
+	if err != nil {
+		return nil, nil, err
+	}
+	tok, err := a.Tokens.Load()
+	if errors.Is(err, ErrNotAuthorized) {
+		tok, err = importLegacyToken(a.Tokens)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	source := &persistingTokenSource{
+		source: config.TokenSource(ctx, tok),
+		store:  a.Tokens,
+		saved:  tok.AccessToken,
 	}
-	state := base64.URLEncoding.EncodeToString(b)
+	return oauth2.NewClient(ctx, source), source, nil
+}
 
-	// Use a channel to receive the code from the HTTP handler
-	codeCh := make(chan string)
-	errCh := make(chan error)
+// LoopbackFlow sends the browser back to a server on this machine, which
+// catches the code. It needs a browser on the same machine.
+type LoopbackFlow struct {
+	// Port is where the server listens; zero picks a free port.
+	Port int
+	Out  io.Writer
+}
 
-	// Define a simple handler to catch the code from the redirect
-	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Verify state
-		if r.URL.Query().Get("state") != state {
-			errCh <- fmt.Errorf("invalid state token")
-			fmt.Fprintf(w, "Error: Invalid state token.")
-			return
-		}
+func (f LoopbackFlow) Token(ctx context.Context, config *oauth2.Config) (*oauth2.Token, error) {
+	ln, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(f.Port)))
+	if err != nil {
+		return nil, fmt.Errorf("listening for the redirect: %w", err)
+	}
+	cfg := *config
+	cfg.RedirectURL = "http://" + ln.Addr().String()
+
+	state, err := randomState()
+	if err != nil {
+		return nil, err
+	}
+	verifier := oauth2.GenerateVerifier()
 
-		code := r.URL.Query().Get("code")
-		if code == "" {
-			errCh <- fmt.Errorf("no code in redirect")
-			fmt.Fprintf(w, "Error: No code found in the redirect.")
+	type result struct {
+		code string
+		err  error
+	}
+	results := make(chan result, 1)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
 			return
 		}
-		fmt.Fprintf(w, "Authorization successful! You can close this window.")
-		codeCh <- code
-	})
-
-	// Start a local server on the port expected by the redirect URI
-	// Since credentials.json has http://localhost, it usually implies port 80 or a random port if configured.
-	// However, Google allows http://localhost without a port for some app types,
-	// but it's better to be explicit if we can.
-	// Looking at the redirect_uris: ["http://localhost"]
-	port := "8888"
-	config.RedirectURL = "http://localhost:" + port
-	server := &http.Server{Addr: "localhost:" + port, Handler: handler}
-
-	go func() {
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			errCh <- err
+		code, err := authCode(r.URL.Query(), state)
+		if err != nil {
+			http.Error(w, "Authorization failed: "+err.Error(), http.StatusBadRequest)
+		} else {
+			fmt.Fprint(w, "Authorization successful! You can close this window.")
+		}
+		select {
+		case results <- result{code, err}:
+		default:
 		}
-	}()
+	})}
+	go func() { _ = server.Serve(ln) }()
+	defer server.Close()
 
-	authURL := config.AuthCodeURL(state, oauth2.AccessTypeOffline)
-	fmt.Printf("Go to the following link in your browser: \n%v\n", authURL)
+	authURL := cfg.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.S256ChallengeOption(verifier))
+	fmt.Fprintf(output(f.Out), "Go to the following link in your browser:\n%v\n", authURL)
 
-	var authCode string
+	ctx, cancel := context.WithTimeout(ctx, authTimeout)
+	defer cancel()
 	select {
-	case authCode = <-codeCh:
-		// Received the code!
-	case err := <-errCh:
-		log.Fatalf("Error during authentication: %v", err)
-	case <-time.After(2 * time.Minute):
-		log.Fatalf("Timeout waiting for authentication code")
+	case r := <-results:
+		if r.err != nil {
+			return nil, r.err
+		}
+		return cfg.Exchange(ctx, r.code, oauth2.VerifierOption(verifier))
+	case <-ctx.Done():
+		return nil, fmt.Errorf("waiting for the browser: %w", ctx.Err())
 	}
+}
+
+// PasteFlow has the user open the link anywhere and paste back the address
+// the browser ends up on, which fails to load but holds the code. It suits
+// a machine reached over SSH.
+type PasteFlow struct {
+	In  io.Reader
+	Out io.Writer
+}
 
-	// Shut down the server
-	err := server.Close()
+func (f PasteFlow) Token(ctx context.Context, config *oauth2.Config) (*oauth2.Token, error) {
+	state, err := randomState()
 	if err != nil {
-		log.Fatalf("Error shutting down the server: %v", err)
+		return nil, err
 	}
+	verifier := oauth2.GenerateVerifier()
+	authURL := config.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.S256ChallengeOption(verifier))
+	out := output(f.Out)
+	fmt.Fprintf(out, "Go to the following link in any browser:\n%v\n\n", authURL)
+	fmt.Fprint(out, "After approving, the browser is sent to a page that will not load. Paste its address here: ")
 
-	tok, err := config.Exchange(context.Background(), authCode)
+	in := f.In
+	if in == nil {
+		in = os.Stdin
+	}
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && line == "" {
+		return nil, fmt.Errorf("reading the code: %w", err)
+	}
+	code, err := pastedCode(strings.TrimSpace(line), state)
 	if err != nil {
-		log.Fatalf("Unable to retrieve token from web: %v", err)
+		return nil, err
 	}
-	return tok
+	return config.Exchange(ctx, code, oauth2.VerifierOption(verifier))
 }
 
-// Retrieves a token from a local file.
-func tokenFromFile(file string) (*oauth2.Token, error) {
-	f, err := os.Open(file)
+// pastedCode takes the code from a pasted redirect address, or the code
+// itself.
+func pastedCode(pasted, state string) (string, error) {
+	if !strings.Contains(pasted, "://") {
+		if pasted == "" {
+			return "", errors.New("no code pasted")
+		}
+		return pasted, nil
+	}
+	u, err := url.Parse(pasted)
 	if err != nil {
-		return nil, err
+		return "", fmt.Errorf("reading the pasted address: %w", err)
 	}
-	defer func() {
-		errClose := f.Close()
-		if errClose != nil {
-			log.Fatalf("Error closing token file: %v", errClose)
-		}
-	}()
-	tok := &oauth2.Token{}
-	err = json.NewDecoder(f).Decode(tok)
-	return tok, err
+	return authCode(u.Query(), state)
 }
 
-// Saves a token to a file path.
-func saveToken(path string, token *oauth2.Token) {
-	fmt.Printf("Saving credential file to: %s\n", path)
-	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+// DeviceFlow shows a code to enter at google.com/device from any other
+// device. Google only offers it to OAuth clients of the "TVs and Limited
+// Input devices" type and restricts the scopes it grants; when it refuses,
+// use PasteFlow.
+type DeviceFlow struct {
+	Out io.Writer
+}
+
+func (f DeviceFlow) Token(ctx context.Context, config *oauth2.Config) (*oauth2.Token, error) {
+	cfg := *config
+	if cfg.Endpoint.DeviceAuthURL == "" {
+		cfg.Endpoint.DeviceAuthURL = google.Endpoint.DeviceAuthURL
+	}
+	da, err := cfg.DeviceAuth(ctx)
 	if err != nil {
-		log.Fatalf("Unable to cache oauth token: %v", err)
+		return nil, fmt.Errorf("requesting a device code: %w", err)
 	}
-	defer func() {
-		errClose := f.Close()
-		if errClose != nil {
-			log.Fatalf("Error closing token file: %v", errClose)
-		}
-	}()
-	if err := json.NewEncoder(f).Encode(token); err != nil {
-		log.Fatalf("Unable to cache oauth token: %v", err)
+	fmt.Fprintf(output(f.Out), "Go to %s and enter the code %s\n", da.VerificationURI, da.UserCode)
+	return cfg.DeviceAccessToken(ctx, da)
+}
+
+// authCode reads the code from the redirect's query, checking it answers
+// our request.
+func authCode(query url.Values, state string) (string, error) {
+	if reason := query.Get("error"); reason != "" {
+		return "", fmt.Errorf("authorization denied: %s", reason)
+	}
+	if query.Get("state") != state {
+		return "", errors.New("invalid state token")
+	}
+	code := query.Get("code")
+	if code == "" {
+		return "", errors.New("no code in redirect")
+	}
+	return code, nil
+}
+
+func randomState() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating state: %w", err)
+	}
+	return base64.URLEncoding.EncodeToString(b), nil
+}
+
+func output(w io.Writer) io.Writer {
+	if w == nil {
+		return os.Stdout
+	}
+	return w
+}
+
+// importLegacyToken moves a token.json left in the working directory into
+// the store.
+func importLegacyToken(store TokenStore) (*oauth2.Token, error) {
+	data, err := os.ReadFile(legacyTokenFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotAuthorized
+	}
+	if err != nil {
+		return nil, err
+	}
+	tok := &oauth2.Token{}
+	if err := json.Unmarshal(data, tok); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", legacyTokenFile, err)
+	}
+	if err := store.Save(tok); err != nil {
+		return nil, err
 	}
+	return tok, os.Remove(legacyTokenFile)
 }
@@ -0,0 +1,276 @@
+package calendar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// fakeTokenServer exchanges the code "good-code" for a token, requiring the
+// PKCE verifier.
+func fakeTokenServer(t *testing.T) *oauth2.Config {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if r.Form.Get("code") != "good-code" || r.Form.Get("code_verifier") == "" {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token":"access","refresh_token":"refresh","token_type":"Bearer","expires_in":3600}`)
+	}))
+	t.Cleanup(server.Close)
+	return &oauth2.Config{
+		ClientID:    "client",
+		RedirectURL: "http://localhost",
+		Endpoint:    oauth2.Endpoint{AuthURL: server.URL + "/auth", TokenURL: server.URL + "/token"},
+	}
+}
+
+// authLink reads the link a flow prints.
+func authLink(t *testing.T, r io.Reader) *url.URL {
+	t.Helper()
+	buf := make([]byte, 4096)
+	var printed string
+	for !strings.Contains(printed, "state=") || !strings.Contains(printed[strings.Index(printed, "state="):], "\n") {
+		n, err := r.Read(buf)
+		if err != nil {
+			t.Fatalf("Expected a link, got %q: %v", printed, err)
+		}
+		printed += string(buf[:n])
+	}
+	for _, line := range strings.Split(printed, "\n") {
+		if strings.HasPrefix(line, "http") {
+			u, err := url.Parse(line)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return u
+		}
+	}
+	t.Fatalf("Expected a link, got %q", printed)
+	return nil
+}
+
+func TestLoopbackFlow(t *testing.T) {
+	config := fakeTokenServer(t)
+	out, printed := io.Pipe()
+	type result struct {
+		tok *oauth2.Token
+		err error
+	}
+	done := make(chan result)
+	go func() {
+		tok, err := LoopbackFlow{Out: printed}.Token(context.Background(), config)
+		done <- result{tok, err}
+	}()
+
+	link := authLink(t, out)
+	go io.Copy(io.Discard, out)
+	q := link.Query()
+	if q.Get("code_challenge") == "" || q.Get("access_type") != "offline" {
+		t.Errorf("Expected an offline PKCE request, got %v", q)
+	}
+	redirect := q.Get("redirect_uri")
+	if !strings.HasPrefix(redirect, "http://127.0.0.1:") || redirect == "http://127.0.0.1:8888" {
+		t.Errorf("Expected a redirect to a free loopback port, got %s", redirect)
+	}
+
+	resp, err := http.Get(redirect + "/?state=" + url.QueryEscape(q.Get("state")) + "&code=good-code")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	r := <-done
+	if r.err != nil {
+		t.Fatalf("Expected a token, got %v", r.err)
+	}
+	if r.tok.AccessToken != "access" || r.tok.RefreshToken != "refresh" {
+		t.Errorf("Expected the exchanged token, got %+v", r.tok)
+	}
+}
+
+func TestLoopbackFlow_Denied(t *testing.T) {
+	config := fakeTokenServer(t)
+	out, printed := io.Pipe()
+	done := make(chan error)
+	go func() {
+		_, err := LoopbackFlow{Out: printed}.Token(context.Background(), config)
+		done <- err
+	}()
+	link := authLink(t, out)
+	go io.Copy(io.Discard, out)
+
+	resp, err := http.Get(link.Query().Get("redirect_uri") + "/?error=access_denied")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if err := <-done; err == nil || !strings.Contains(err.Error(), "access_denied") {
+		t.Errorf("Expected the denial as an error, got %v", err)
+	}
+}
+
+func TestPasteFlow(t *testing.T) {
+	config := fakeTokenServer(t)
+	out, printed := io.Pipe()
+	in, paste := io.Pipe()
+	type result struct {
+		tok *oauth2.Token
+		err error
+	}
+	done := make(chan result)
+	go func() {
+		tok, err := PasteFlow{In: in, Out: printed}.Token(context.Background(), config)
+		done <- result{tok, err}
+	}()
+
+	link := authLink(t, out)
+	go io.Copy(io.Discard, out)
+	state := url.QueryEscape(link.Query().Get("state"))
+	fmt.Fprintf(paste, "  http://localhost/?state=%s&code=good-code&scope=calendar  \n", state)
+
+	r := <-done
+	if r.err != nil {
+		t.Fatalf("Expected a token, got %v", r.err)
+	}
+	if r.tok.AccessToken != "access" {
+		t.Errorf("Expected the exchanged token, got %+v", r.tok)
+	}
+}
+
+func TestPastedCode(t *testing.T) {
+	tests := []struct {
+		pasted string
+		code   string
+		fails  bool
+	}{
+		{"4/0Abc", "4/0Abc", false},
+		{"http://localhost/?state=s&code=4%2F0Abc", "4/0Abc", false},
+		{"http://localhost/?state=other&code=4%2F0Abc", "", true},
+		{"http://localhost/?error=access_denied", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		code, err := pastedCode(tt.pasted, "s")
+		if (err != nil) != tt.fails || code != tt.code {
+			t.Errorf("%q: expected %q (fails %v), got %q, %v", tt.pasted, tt.code, tt.fails, code, err)
+		}
+	}
+}
+
+// handlerTransport serves requests straight from a handler, so a flow can
+// poll it on synctest's fake clock.
+type handlerTransport struct{ http.Handler }
+
+func (h handlerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w.Result(), nil
+}
+
+// fakeDeviceServer hands out the device code "device", valid for
+// expiresIn seconds and polled every five, and answers polls with token.
+func fakeDeviceServer(expiresIn int, token http.HandlerFunc) (context.Context, *oauth2.Config) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/device", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"device_code":"device","user_code":"ABCD-EFGH","verification_url":"https://www.google.com/device","expires_in":%d,"interval":5}`, expiresIn)
+	})
+	mux.HandleFunc("/token", token)
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: handlerTransport{mux}})
+	return ctx, &oauth2.Config{
+		ClientID: "client",
+		Endpoint: oauth2.Endpoint{
+			DeviceAuthURL: "https://oauth2.example.com/device",
+			TokenURL:      "https://oauth2.example.com/token",
+			AuthStyle:     oauth2.AuthStyleInParams,
+		},
+	}
+}
+
+func TestDeviceFlow(t *testing.T) {
+	tests := []struct {
+		name    string
+		replies []string // the token endpoint's error per poll; "" grants the token
+		gaps    []time.Duration
+		err     string
+	}{
+		{"approved", []string{"authorization_pending", "slow_down", ""}, []time.Duration{5 * time.Second, 10 * time.Second}, ""},
+		{"expired", []string{"authorization_pending", "expired_token"}, []time.Duration{5 * time.Second}, "expired_token"},
+		{"denied", []string{"access_denied"}, nil, "access_denied"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			synctest.Test(t, func(t *testing.T) {
+				var polls []time.Time
+				ctx, config := fakeDeviceServer(1800, func(w http.ResponseWriter, r *http.Request) {
+					_ = r.ParseForm()
+					if r.Form.Get("device_code") != "device" {
+						t.Errorf("Expected the device code to be polled, got %v", r.Form)
+					}
+					polls = append(polls, time.Now())
+					w.Header().Set("Content-Type", "application/json")
+					if reply := tt.replies[len(polls)-1]; reply != "" {
+						w.WriteHeader(http.StatusBadRequest)
+						fmt.Fprintf(w, `{"error":%q}`, reply)
+						return
+					}
+					fmt.Fprint(w, `{"access_token":"access","refresh_token":"refresh","token_type":"Bearer","expires_in":3600}`)
+				})
+
+				var out strings.Builder
+				tok, err := DeviceFlow{Out: &out}.Token(ctx, config)
+				if !strings.Contains(out.String(), "https://www.google.com/device") || !strings.Contains(out.String(), "ABCD-EFGH") {
+					t.Errorf("Expected the link and code to be printed, got %q", out.String())
+				}
+				if tt.err == "" && (err != nil || tok.AccessToken != "access") {
+					t.Errorf("Expected the granted token, got %+v, %v", tok, err)
+				}
+				if tt.err != "" {
+					var re *oauth2.RetrieveError
+					if !errors.As(err, &re) || re.ErrorCode != tt.err {
+						t.Errorf("Expected %s, got %v", tt.err, err)
+					}
+				}
+				if len(polls) != len(tt.replies) {
+					t.Fatalf("Expected %d polls, got %d", len(tt.replies), len(polls))
+				}
+				for i, gap := range tt.gaps {
+					if got := polls[i+1].Sub(polls[i]); got != gap {
+						t.Errorf("Expected poll %d %v after the last, got %v", i+2, gap, got)
+					}
+				}
+			})
+		})
+	}
+}
+
+func TestDeviceFlow_CodeExpires(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		ctx, config := fakeDeviceServer(60, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":"authorization_pending"}`)
+		})
+
+		start := time.Now()
+		_, err := DeviceFlow{Out: io.Discard}.Token(ctx, config)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected the wait to end when the code expires, got %v", err)
+		}
+		if waited := time.Since(start); waited != time.Minute {
+			t.Errorf("Expected to poll until the code expired after a minute, got %v", waited)
+		}
+	})
+}
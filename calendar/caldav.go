@@ -0,0 +1,109 @@
+package calendar
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CalDAVCalendar queries a calendar collection on a CalDAV server such as
+// Radicale, Nextcloud or Fastmail.
+type CalDAVCalendar struct {
+	// URL is the calendar collection, for example
+	// https://dav.example.com/user/work/.
+	URL      string
+	Username string
+	Password string
+	// Emails are our own addresses, used to find our RSVP.
+	Emails []string
+	Client *http.Client
+}
+
+// NewCalDAV returns a provider for the collection at url.
+func NewCalDAV(url, username, password string) *CalDAVCalendar {
+	return &CalDAVCalendar{
+		URL:      url,
+		Username: username,
+		Password: password,
+		Client:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+const calendarQuery = `<?xml version="1.0" encoding="utf-8"?>
+<c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop>
+    <d:getetag/>
+    <c:calendar-data/>
+  </d:prop>
+  <c:filter>
+    <c:comp-filter name="VCALENDAR">
+      <c:comp-filter name="VEVENT">
+        <c:time-range start="%s" end="%s"/>
+      </c:comp-filter>
+    </c:comp-filter>
+  </c:filter>
+</c:calendar-query>`
+
+// multistatus is the part of a WebDAV 207 response the calendar-query
+// needs.
+type multistatus struct {
+	Responses []struct {
+		Href      string `xml:"DAV: href"`
+		Propstats []struct {
+			Status string `xml:"DAV: status"`
+			Prop   struct {
+				CalendarData string `xml:"urn:ietf:params:xml:ns:caldav calendar-data"`
+			} `xml:"DAV: prop"`
+		} `xml:"DAV: propstat"`
+	} `xml:"DAV: response"`
+}
+
+// EventsBetween runs a calendar-query REPORT for [start, end). The server
+// returns whole calendar objects, series included, which are expanded
+// here the same way as an ICS feed.
+func (c *CalDAVCalendar) EventsBetween(start, end time.Time) ([]Event, error) {
+	body := fmt.Sprintf(calendarQuery, start.UTC().Format("20060102T150405Z"), end.UTC().Format("20060102T150405Z"))
+	req, err := http.NewRequest("REPORT", c.URL, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	req.Header.Set("Depth", "1")
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, fmt.Errorf("CalDAV query: %s", resp.Status)
+	}
+
+	var ms multistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return nil, fmt.Errorf("CalDAV query: %w", err)
+	}
+	var vevents []VEvent
+	for _, r := range ms.Responses {
+		for _, ps := range r.Propstats {
+			if !strings.Contains(ps.Status, " 200 ") || ps.Prop.CalendarData == "" {
+				continue
+			}
+			parsed, err := ParseICS(strings.NewReader(ps.Prop.CalendarData))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", r.Href, err)
+			}
+			vevents = append(vevents, parsed...)
+		}
+	}
+	return eventsBetween(vevents, start, end, c.Emails), nil
+}
@@ -0,0 +1,99 @@
+package calendar
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// radicale stands in for a Radicale server holding one collection with the
+// given calendar objects.
+func radicale(t *testing.T, objects map[string]string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "alice" || pass != "secret" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="Radicale"`)
+			http.Error(w, "Access to the requested resource forbidden.", http.StatusUnauthorized)
+			return
+		}
+		if r.Method != "REPORT" || r.URL.Path != "/alice/work/" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if r.Header.Get("Depth") != "1" {
+			http.Error(w, "Depth must be 1", http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), `<c:time-range start="20230315T000000Z" end="20230316T000000Z"/>`) {
+			http.Error(w, "Unexpected query", http.StatusBadRequest)
+			return
+		}
+
+		var b strings.Builder
+		b.WriteString(`<?xml version='1.0' encoding='utf-8'?>` + "\n")
+		b.WriteString(`<multistatus xmlns="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">`)
+		for name, data := range objects {
+			fmt.Fprintf(&b, `<response><href>/alice/work/%s</href><propstat><prop><getetag>"%s"</getetag>`, name, name)
+			b.WriteString(`<C:calendar-data>`)
+			xml.EscapeText(&b, []byte(data))
+			b.WriteString(`</C:calendar-data></prop><status>HTTP/1.1 200 OK</status></propstat></response>`)
+		}
+		b.WriteString(`<response><href>/alice/work/missing.ics</href><propstat><prop><C:calendar-data/></prop>`)
+		b.WriteString(`<status>HTTP/1.1 404 Not Found</status></propstat></response>`)
+		b.WriteString(`</multistatus>`)
+
+		w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		w.WriteHeader(http.StatusMultiStatus)
+		io.WriteString(w, b.String())
+	}))
+}
+
+func TestCalDAVCalendar_EventsBetween(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "google-recurring.ics"))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	server := radicale(t, map[string]string{"recurring.ics": string(data)})
+	defer server.Close()
+
+	cal := NewCalDAV(server.URL+"/alice/work/", "alice", "secret")
+	day := time.Date(2023, 3, 15, 0, 0, 0, 0, time.UTC)
+	events, err := cal.EventsBetween(day, day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("Failed to query CalDAV: %v", err)
+	}
+
+	var names []string
+	for _, e := range events {
+		names = append(names, e.Name)
+	}
+	if strings.Join(names, "|") != "Standup (late)|Demo" {
+		t.Errorf("Expected the moved standup and the demo, got %v", names)
+	}
+	if events[0].UID != "5abc123def456@google.com" || events[0].SeriesID != events[0].UID {
+		t.Errorf("Expected the event UID as its series, got %q and %q", events[0].UID, events[0].SeriesID)
+	}
+	if events[1].SeriesID == "" {
+		t.Error("Expected the biweekly demo to belong to a series")
+	}
+}
+
+func TestCalDAVCalendar_Unauthorized(t *testing.T) {
+	server := radicale(t, nil)
+	defer server.Close()
+
+	cal := NewCalDAV(server.URL+"/alice/work/", "alice", "wrong")
+	day := time.Date(2023, 3, 15, 0, 0, 0, 0, time.UTC)
+	if _, err := cal.EventsBetween(day, day.AddDate(0, 0, 1)); err == nil {
+		t.Error("Expected error for a rejected login, got nil")
+	}
+}
@@ -2,75 +2,214 @@ package calendar
 
 import (
 	"context"
-	"os"
+	"fmt"
+	"strings"
 	"time"
 
-	"golang.org/x/oauth2/google"
 	"google.golang.org/api/calendar/v3"
 	"google.golang.org/api/option"
 )
 
+// ResponseStatus is our own answer to an invitation, using Google's names.
+type ResponseStatus string
+
+const (
+	ResponseNone        ResponseStatus = ""
+	ResponseNeedsAction ResponseStatus = "needsAction"
+	ResponseAccepted    ResponseStatus = "accepted"
+	ResponseTentative   ResponseStatus = "tentative"
+	ResponseDeclined    ResponseStatus = "declined"
+)
+
 type Event struct {
-	Name  string `json:"name"`
-	Start string `json:"start"`
-	End   string `json:"end"`
-	Type  string `json:"type"`
+	// UID is the iCalendar UID, shared by a recurring event's occurrences
+	// and by the same event seen through different providers.
+	UID         string    `json:"uid,omitempty"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	// AllDay events start and end at local midnight.
+	AllDay bool   `json:"allDay,omitempty"`
+	Type   string `json:"type"`
+	// Attendees counts everyone invited, ourselves included.
+	Attendees int            `json:"attendees,omitempty"`
+	Response  ResponseStatus `json:"response,omitempty"`
+	// Organizer is set when we organise the event.
+	Organizer     bool   `json:"organizer,omitempty"`
+	Location      string `json:"location,omitempty"`
+	ConferenceURL string `json:"conferenceUrl,omitempty"`
+	// SeriesID identifies the recurring series an occurrence belongs to.
+	SeriesID string `json:"seriesId,omitempty"`
+}
+
+// Tentative reports whether we have not firmly accepted the event.
+func (e Event) Tentative() bool {
+	return e.Response == ResponseTentative || e.Response == ResponseNeedsAction
+}
+
+// String is the compact form given to the model.
+func (e Event) String() string {
+	var b strings.Builder
+	if e.AllDay {
+		b.WriteString("all day")
+	} else {
+		b.WriteString(e.Start.Format("15:04") + "-" + e.End.Format("15:04"))
+	}
+	b.WriteString(" " + e.Name)
+	var notes []string
+	switch {
+	case e.Response == ResponseTentative:
+		notes = append(notes, "tentative")
+	case e.Response == ResponseNeedsAction:
+		notes = append(notes, "not answered")
+	}
+	if e.Type == "focusTime" {
+		notes = append(notes, "focus time")
+	}
+	if e.Organizer {
+		notes = append(notes, "we organise")
+	}
+	if e.Attendees > 0 {
+		plural := "s"
+		if e.Attendees == 1 {
+			plural = ""
+		}
+		notes = append(notes, fmt.Sprintf("%d attendee%s", e.Attendees, plural))
+	}
+	if e.SeriesID != "" {
+		notes = append(notes, "recurring")
+	}
+	if len(notes) > 0 {
+		b.WriteString(" (" + strings.Join(notes, ", ") + ")")
+	}
+	return b.String()
 }
 
 func filterEvents(events []*calendar.Event) []Event {
 	validEventTypes := make(map[string]struct{})
 	validEventTypes["event"] = struct{}{}
+	validEventTypes["default"] = struct{}{} // what the API reports for ordinary events
 	validEventTypes["focusTime"] = struct{}{}
 	var filteredEvents []Event
 	for _, e := range events {
-		if _, ok := validEventTypes[e.EventType]; ok {
-			start := e.Start.DateTime
-			if start == "" {
-				start = e.Start.Date
+		if _, ok := validEventTypes[e.EventType]; !ok {
+			continue
+		}
+		start, allDay, err := googleTime(e.Start)
+		if err != nil {
+			continue
+		}
+		end, _, err := googleTime(e.End)
+		if err != nil {
+			continue
+		}
+		event := Event{
+			UID:         e.ICalUID,
+			Name:        e.Summary,
+			Description: e.Description,
+			Start:       start,
+			End:         end,
+			AllDay:      allDay,
+			Type:        e.EventType,
+			Attendees:   len(e.Attendees),
+			Organizer:   e.Organizer != nil && e.Organizer.Self,
+			Location:    e.Location,
+			SeriesID:    e.RecurringEventId,
+		}
+		for _, a := range e.Attendees {
+			if a.Self {
+				event.Response = ResponseStatus(a.ResponseStatus)
 			}
-			end := e.End.DateTime
-			if end == "" {
-				end = e.End.Date
+		}
+		if event.Response == ResponseDeclined {
+			continue
+		}
+		event.ConferenceURL = e.HangoutLink
+		if e.ConferenceData != nil {
+			for _, ep := range e.ConferenceData.EntryPoints {
+				if ep.EntryPointType == "video" {
+					event.ConferenceURL = ep.Uri
+					break
+				}
 			}
-			filteredEvents = append(filteredEvents, Event{
-				Name:  e.Summary,
-				Start: start,
-				End:   end,
-				Type:  e.EventType,
-			})
 		}
+		filteredEvents = append(filteredEvents, event)
 	}
 
 	return filteredEvents
 }
 
+// googleTime reads an event time, which holds either a date for all-day
+// events or an RFC 3339 time. Times are returned in the local zone so they
+// print as the user's clock, not the organiser's.
+func googleTime(t *calendar.EventDateTime) (time.Time, bool, error) {
+	if t == nil {
+		return time.Time{}, false, fmt.Errorf("missing event time")
+	}
+	if t.DateTime != "" {
+		parsed, err := time.Parse(time.RFC3339, t.DateTime)
+		return parsed.In(time.Local), false, err
+	}
+	parsed, err := time.ParseInLocation(time.DateOnly, t.Date, time.Local)
+	return parsed, true, err
+}
+
+// googlePageSize is the most events the API returns per page.
+const googlePageSize = 250
+
 type GoogleCalendarIntegration struct {
 	calendarService *calendar.Service
+	tokens          *persistingTokenSource
+	// CalendarIDs are the calendars read; the primary calendar when empty.
+	CalendarIDs []string
 }
 
-func (c GoogleCalendarIntegration) GetCalendarEvents(start time.Time) ([]Event, error) {
+// EventsBetween lists the events overlapping [start, end) in every
+// calendar, following the result pages to the end.
+func (c GoogleCalendarIntegration) EventsBetween(start, end time.Time) ([]Event, error) {
 	minTime := start.Format(time.RFC3339)
-	maxTime := start.Add(24 * time.Hour).Format(time.RFC3339)
-	events, err := c.calendarService.Events.List("primary").ShowDeleted(false).
-		SingleEvents(true).TimeMin(minTime).TimeMax(maxTime).MaxResults(10).OrderBy("startTime").Do()
-	if err != nil {
-		return nil, err
+	maxTime := end.Format(time.RFC3339)
+	var events []Event
+	for _, id := range c.calendarIDs() {
+		call := c.calendarService.Events.List(id).ShowDeleted(false).
+			SingleEvents(true).TimeMin(minTime).TimeMax(maxTime).MaxResults(googlePageSize).OrderBy("startTime")
+		err := call.Pages(context.Background(), func(page *calendar.Events) error {
+			events = append(events, filterEvents(page.Items)...)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("calendar %s: %w", id, err)
+		}
 	}
-	return filterEvents(events.Items), nil
+	return Dedupe(events), nil
 }
 
-func New(ctx context.Context) (*GoogleCalendarIntegration, error) {
-	b, err := os.ReadFile("credentials.json")
-	if err != nil {
-		return nil, err
+// Err returns why a refreshed token could not be saved, or nil. Requests
+// still succeed with the refreshed token, but the next run has to refresh
+// it again.
+func (c GoogleCalendarIntegration) Err() error {
+	if c.tokens == nil {
+		return nil
 	}
+	return c.tokens.Err()
+}
+
+func (c GoogleCalendarIntegration) calendarIDs() []string {
+	if len(c.CalendarIDs) == 0 {
+		return []string{"primary"}
+	}
+	return c.CalendarIDs
+}
 
-	// If modifying these scopes, delete your previously saved token.json.
-	config, err := google.ConfigFromJSON(b, calendar.CalendarReadonlyScope)
+// New connects to Google Calendar and reads the given calendars, or the
+// primary calendar when none are given. It fails with ErrNotAuthorized
+// until auth.Authorize has saved a token.
+func New(ctx context.Context, auth GoogleAuth, calendarIDs ...string) (*GoogleCalendarIntegration, error) {
+	client, tokens, err := auth.client(ctx)
 	if err != nil {
 		return nil, err
 	}
-	client := getClient(config)
 
 	svr, err := calendar.NewService(ctx, option.WithHTTPClient(client))
 	if err != nil {
@@ -78,5 +217,7 @@ func New(ctx context.Context) (*GoogleCalendarIntegration, error) {
 	}
 	return &GoogleCalendarIntegration{
 		calendarService: svr,
+		tokens:          tokens,
+		CalendarIDs:     calendarIDs,
 	}, nil
 }
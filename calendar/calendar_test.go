@@ -1,9 +1,16 @@
 package calendar
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/option"
 )
 
 func TestFilterEvents(t *testing.T) {
@@ -42,18 +49,161 @@ func TestFilterEvents(t *testing.T) {
 
 	for _, e := range filtered {
 		if e.Name == "All-Day Event" {
-			if e.Start == "" {
-				t.Errorf("All-Day Event Start is empty")
+			if !e.AllDay {
+				t.Errorf("Expected All-Day Event to be all day")
 			}
-			if e.End == "" {
-				t.Errorf("All-Day Event End is empty")
+			if got := e.Start.Format(time.DateOnly); got != "2023-10-27" {
+				t.Errorf("Expected Start '2023-10-27', got '%s'", got)
 			}
-			if e.Start != "2023-10-27" {
-				t.Errorf("Expected Start '2023-10-27', got '%s'", e.Start)
+			if got := e.End.Format(time.DateOnly); got != "2023-10-28" {
+				t.Errorf("Expected End '2023-10-28', got '%s'", got)
 			}
-			if e.End != "2023-10-28" {
-				t.Errorf("Expected End '2023-10-28', got '%s'", e.End)
+		}
+		if e.Name == "Normal Event" {
+			if e.AllDay {
+				t.Errorf("Expected Normal Event not to be all day")
+			}
+			if !e.Start.Equal(time.Date(2023, 10, 27, 10, 0, 0, 0, time.UTC)) {
+				t.Errorf("Expected Start 10:00 UTC, got %v", e.Start)
 			}
 		}
 	}
 }
+
+func TestFilterEvents_Attendance(t *testing.T) {
+	at := func(hour int) *calendar.EventDateTime {
+		return &calendar.EventDateTime{DateTime: time.Date(2023, 10, 27, hour, 0, 0, 0, time.UTC).Format(time.RFC3339)}
+	}
+	events := []*calendar.Event{
+		{
+			Summary:   "Declined",
+			EventType: "event",
+			Start:     at(9),
+			End:       at(10),
+			Attendees: []*calendar.EventAttendee{
+				{Email: "me@example.com", Self: true, ResponseStatus: "declined"},
+				{Email: "boss@example.com", ResponseStatus: "accepted"},
+			},
+		},
+		{
+			Summary:          "Design Review",
+			EventType:        "event",
+			ICalUID:          "review@google.com",
+			RecurringEventId: "review123",
+			Location:         "Room 4",
+			Start:            at(10),
+			End:              at(11),
+			Organizer:        &calendar.EventOrganizer{Email: "me@example.com", Self: true},
+			Attendees: []*calendar.EventAttendee{
+				{Email: "me@example.com", Self: true, ResponseStatus: "accepted"},
+				{Email: "a@example.com", ResponseStatus: "tentative"},
+				{Email: "b@example.com", ResponseStatus: "needsAction"},
+			},
+			ConferenceData: &calendar.ConferenceData{EntryPoints: []*calendar.EntryPoint{
+				{EntryPointType: "phone", Uri: "tel:+1-555-0100"},
+				{EntryPointType: "video", Uri: "https://meet.google.com/abc-defg-hij"},
+			}},
+		},
+		{
+			Summary:   "Maybe",
+			EventType: "event",
+			Start:     at(13),
+			End:       at(14),
+			Attendees: []*calendar.EventAttendee{{Email: "me@example.com", Self: true, ResponseStatus: "tentative"}},
+		},
+	}
+
+	filtered := filterEvents(events)
+	if len(filtered) != 2 {
+		t.Fatalf("Expected the declined event to be dropped, got %d events", len(filtered))
+	}
+
+	review := filtered[0]
+	if review.Attendees != 3 || !review.Organizer || review.Response != ResponseAccepted {
+		t.Errorf("Expected 3 attendees, organizer and accepted, got %+v", review)
+	}
+	if review.ConferenceURL != "https://meet.google.com/abc-defg-hij" {
+		t.Errorf("Expected the video entry point, got %q", review.ConferenceURL)
+	}
+	if review.SeriesID != "review123" || review.UID != "review@google.com" || review.Location != "Room 4" {
+		t.Errorf("Expected series, UID and location, got %+v", review)
+	}
+	if !filtered[1].Tentative() {
+		t.Errorf("Expected Maybe to be tentative")
+	}
+	if got, want := filtered[1].String(), "13:00-14:00 Maybe (tentative, 1 attendee)"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestGoogleEventsBetween_Pages(t *testing.T) {
+	event := func(uid, start string) *calendar.Event {
+		return &calendar.Event{
+			ICalUID:   uid,
+			Summary:   uid,
+			EventType: "default",
+			Start:     &calendar.EventDateTime{DateTime: start},
+			End:       &calendar.EventDateTime{DateTime: strings.Replace(start, ":00:00", ":30:00", 1)},
+		}
+	}
+	pages := map[string][]*calendar.Events{
+		"primary": {
+			{Items: []*calendar.Event{event("a", "2024-03-04T09:00:00Z")}, NextPageToken: "next"},
+			{Items: []*calendar.Event{event("b", "2024-03-06T11:00:00Z")}},
+		},
+		"team@example.com": {
+			{Items: []*calendar.Event{event("c", "2024-03-05T10:00:00Z"), event("a", "2024-03-04T09:00:00Z")}},
+		},
+	}
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/calendars/"), "/events")
+		page := 0
+		if r.URL.Query().Get("pageToken") == "next" {
+			page = 1
+		}
+		ranges = append(ranges, r.URL.Query().Get("timeMin")+" "+r.URL.Query().Get("timeMax"))
+		_ = json.NewEncoder(w).Encode(pages[id][page])
+	}))
+	defer server.Close()
+
+	svc, err := calendar.NewService(context.Background(), option.WithEndpoint(server.URL+"/"), option.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := GoogleCalendarIntegration{calendarService: svc, CalendarIDs: []string{"primary", "team@example.com"}}
+	start, end := Week(time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC))
+	events, err := g.EventsBetween(start, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, e := range events {
+		names = append(names, e.Name)
+	}
+	if strings.Join(names, ",") != "a,c,b" {
+		t.Errorf("Expected a,c,b across pages and calendars, got %v", names)
+	}
+	if len(ranges) != 3 || ranges[0] != "2024-03-04T00:00:00Z 2024-03-11T00:00:00Z" {
+		t.Errorf("Expected three requests for the week, got %v", ranges)
+	}
+}
+
+func TestGoogleTime(t *testing.T) {
+	start, allDay, err := googleTime(&calendar.EventDateTime{DateTime: "2024-03-04T09:30:00-08:00"})
+	if err != nil || allDay {
+		t.Fatalf("Unexpected result: %v, %v", allDay, err)
+	}
+	if start.Location() != time.Local {
+		t.Errorf("Expected a local time, got %v", start.Location())
+	}
+	if !start.Equal(time.Date(2024, 3, 4, 17, 30, 0, 0, time.UTC)) {
+		t.Errorf("Expected 17:30 UTC, got %v", start.UTC())
+	}
+
+	day, allDay, err := googleTime(&calendar.EventDateTime{Date: "2024-03-04"})
+	if err != nil || !allDay || !day.Equal(time.Date(2024, 3, 4, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("Expected local midnight for an all-day event, got %v, %v, %v", day, allDay, err)
+	}
+}
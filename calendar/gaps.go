@@ -0,0 +1,128 @@
+package calendar
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// DeepWorkMinimum is the shortest gap suitable for Jira work.
+const DeepWorkMinimum = 2 * time.Hour
+
+// GapKind says what a free interval is good for.
+type GapKind int
+
+const (
+	// Admin gaps are too short for deep work and suit quick tasks.
+	Admin GapKind = iota
+	// DeepWork gaps last at least DeepWorkMinimum.
+	DeepWork
+)
+
+func (k GapKind) String() string {
+	if k == DeepWork {
+		return "deep work"
+	}
+	return "admin"
+}
+
+func (k GapKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// Gap is a free interval of the working day. FocusTime is set when focus
+// time booked in the calendar falls inside it.
+type Gap struct {
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Kind      GapKind   `json:"kind"`
+	FocusTime bool      `json:"focusTime,omitempty"`
+}
+
+func (g Gap) Duration() time.Duration {
+	return g.End.Sub(g.Start)
+}
+
+func (g Gap) String() string {
+	s := fmt.Sprintf("%s-%s (%s, %s)", g.Start.Format("15:04"), g.End.Format("15:04"),
+		formatDuration(g.Duration()), g.Kind)
+	if g.FocusTime {
+		s += " with focus time booked"
+	}
+	return s
+}
+
+func formatDuration(d time.Duration) string {
+	h, m := int(d.Hours()), int(d.Minutes())%60
+	switch {
+	case h == 0:
+		return fmt.Sprintf("%dm", m)
+	case m == 0:
+		return fmt.Sprintf("%dh", h)
+	}
+	return fmt.Sprintf("%dh%02dm", h, m)
+}
+
+// FreeGaps returns the free intervals of day between workStart and workEnd,
+// given as offsets from midnight. Overlapping meetings are merged. All-day
+// events do not block time, and neither does focus time: it is time kept
+// free for work, so it marks the gap holding it instead. Tentative meetings
+// still count as busy.
+func FreeGaps(day time.Time, events []Event, workStart, workEnd time.Duration) []Gap {
+	midnight := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	from, until := midnight.Add(workStart), midnight.Add(workEnd)
+
+	var busy, focus []Gap
+	for _, e := range events {
+		if e.AllDay || e.Response == ResponseDeclined {
+			continue
+		}
+		start, end := maxTime(e.Start, from), minTime(e.End, until)
+		if !end.After(start) {
+			continue
+		}
+		if e.Type == "focusTime" {
+			focus = append(focus, Gap{Start: start, End: end})
+		} else {
+			busy = append(busy, Gap{Start: start, End: end})
+		}
+	}
+
+	sort.Slice(busy, func(i, j int) bool { return busy[i].Start.Before(busy[j].Start) })
+	var gaps []Gap
+	cursor := from
+	for _, b := range append(busy, Gap{Start: until, End: until}) {
+		if b.Start.After(cursor) {
+			gaps = append(gaps, newGap(cursor, b.Start, focus))
+		}
+		cursor = maxTime(cursor, b.End)
+	}
+	return gaps
+}
+
+func newGap(start, end time.Time, focus []Gap) Gap {
+	g := Gap{Start: start, End: end}
+	if g.Duration() >= DeepWorkMinimum {
+		g.Kind = DeepWork
+	}
+	for _, f := range focus {
+		if f.Start.Before(end) && f.End.After(start) {
+			g.FocusTime = true
+		}
+	}
+	return g
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,92 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFreeGaps(t *testing.T) {
+	day := time.Date(2023, 10, 27, 0, 0, 0, 0, time.UTC)
+	events := []Event{
+		testEvent(t, "All-Day Event", "2023-10-27", "2023-10-28", "event"),
+		testEvent(t, "Standup", "2023-10-27T09:30:00Z", "2023-10-27T10:00:00Z", "event"),
+		testEvent(t, "Sync", "2023-10-27T09:45:00Z", "2023-10-27T10:30:00Z", "event"),
+		testEvent(t, "Contained", "2023-10-27T09:50:00Z", "2023-10-27T10:10:00Z", "event"),
+		testEvent(t, "Focus Time", "2023-10-27T11:00:00Z", "2023-10-27T12:00:00Z", "focusTime"),
+		testEvent(t, "Review", "2023-10-27T13:00:00Z", "2023-10-27T14:00:00Z", "event"),
+		testEvent(t, "After Hours", "2023-10-27T16:30:00Z", "2023-10-27T19:00:00Z", "event"),
+	}
+
+	declined := testEvent(t, "Declined", "2023-10-27T15:00:00Z", "2023-10-27T16:00:00Z", "event")
+	declined.Response = ResponseDeclined
+	events = append(events, declined)
+
+	gaps := FreeGaps(day, events, 9*time.Hour, 17*time.Hour)
+	want := []struct {
+		start, end string
+		kind       GapKind
+		focus      bool
+	}{
+		{"09:00", "09:30", Admin, false},
+		{"10:30", "13:00", DeepWork, true},
+		{"14:00", "16:30", DeepWork, false},
+	}
+	if len(gaps) != len(want) {
+		t.Fatalf("Expected %d gaps, got %v", len(want), gaps)
+	}
+	for i, w := range want {
+		g := gaps[i]
+		if g.Start.Format("15:04") != w.start || g.End.Format("15:04") != w.end {
+			t.Errorf("Gap %d: expected %s-%s, got %s-%s", i, w.start, w.end, g.Start.Format("15:04"), g.End.Format("15:04"))
+		}
+		if g.Kind != w.kind {
+			t.Errorf("Gap %d: expected %v, got %v", i, w.kind, g.Kind)
+		}
+		if g.FocusTime != w.focus {
+			t.Errorf("Gap %d: expected focus time %v, got %v", i, w.focus, g.FocusTime)
+		}
+	}
+}
+
+func TestFreeGaps_NoEvents(t *testing.T) {
+	day := time.Date(2023, 10, 27, 0, 0, 0, 0, time.UTC)
+	gaps := FreeGaps(day, nil, 9*time.Hour, 17*time.Hour)
+	if len(gaps) != 1 || gaps[0].Duration() != 8*time.Hour || gaps[0].Kind != DeepWork {
+		t.Errorf("Expected the whole day as one deep work gap, got %v", gaps)
+	}
+}
+
+func TestGap_String(t *testing.T) {
+	g := Gap{
+		Start: time.Date(2023, 10, 27, 10, 30, 0, 0, time.UTC),
+		End:   time.Date(2023, 10, 27, 13, 0, 0, 0, time.UTC),
+		Kind:  DeepWork,
+	}
+	if got, want := g.String(), "10:30-13:00 (2h30m, deep work)"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+// testEvent builds an event from RFC 3339 times, or dates for an all-day
+// event.
+func testEvent(t *testing.T, name, start, end, eventType string) Event {
+	t.Helper()
+	e := Event{Name: name, Type: eventType}
+	var err error
+	if len(start) == len(time.DateOnly) {
+		e.AllDay = true
+		e.Start, err = time.ParseInLocation(time.DateOnly, start, time.Local)
+		if err == nil {
+			e.End, err = time.ParseInLocation(time.DateOnly, end, time.Local)
+		}
+	} else {
+		e.Start, err = time.Parse(time.RFC3339, start)
+		if err == nil {
+			e.End, err = time.Parse(time.RFC3339, end)
+		}
+	}
+	if err != nil {
+		t.Fatalf("Bad event times %s to %s: %v", start, end, err)
+	}
+	return e
+}
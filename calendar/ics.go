@@ -0,0 +1,612 @@
+package calendar
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ICSCalendar reads events from an iCalendar feed: a private ICS URL or a
+// local .ics file.
+type ICSCalendar struct {
+	// Source is an http(s) or webcal URL, a file:// URL or a file path.
+	Source string
+	// Emails are our own addresses, used to find our RSVP.
+	Emails []string
+	Client *http.Client
+}
+
+// NewICS returns an adapter for the feed at source.
+func NewICS(source string) *ICSCalendar {
+	return &ICSCalendar{Source: source, Client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// EventsBetween fetches the feed and returns the events overlapping
+// [start, end).
+func (c *ICSCalendar) EventsBetween(start, end time.Time) ([]Event, error) {
+	body, err := c.open()
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	vevents, err := ParseICS(body)
+	if err != nil {
+		return nil, err
+	}
+	return eventsBetween(vevents, start, end, c.Emails), nil
+}
+
+func (c *ICSCalendar) open() (io.ReadCloser, error) {
+	source := c.Source
+	switch {
+	case strings.HasPrefix(source, "webcal://"):
+		source = "https://" + strings.TrimPrefix(source, "webcal://")
+	case strings.HasPrefix(source, "file://"):
+		return os.Open(strings.TrimPrefix(source, "file://"))
+	case !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://"):
+		return os.Open(source)
+	}
+
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Get(source)
+	if err != nil {
+		// The URL holds the feed's secret, so it is left out of errors.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("fetching calendar feed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("fetching calendar feed: %s", resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// VEvent is an event as it appears in an ICS feed. A recurring event is the
+// series; Expand turns it into occurrences. An override of one occurrence
+// has the same UID and its RecurrenceID set to the start it replaces, which
+// Expand also sets on each occurrence.
+//
+// RRULEs with parts Expand does not support, such as BYHOUR, leave only
+// the first occurrence.
+type VEvent struct {
+	UID         string
+	Summary     string
+	Description string
+	Start       time.Time
+	End         time.Time
+	AllDay      bool
+	Status      string
+	Location    string
+	Organizer   string
+	Attendees   []Attendee
+	// ConferenceURL is the meeting link: Google's and Microsoft's own
+	// properties or RFC 7986 CONFERENCE when present, otherwise a known
+	// meeting service linked from the location or description.
+	ConferenceURL string
+	RRule         string
+	RDates        []time.Time
+	ExDates       []time.Time
+	RecurrenceID  time.Time
+
+	start icsTime
+	rule  *recurrence
+	until time.Time
+}
+
+// Attendee is an ATTENDEE of an event. PartStat is the RFC 5545
+// participation status, such as ACCEPTED.
+type Attendee struct {
+	Email    string
+	Name     string
+	PartStat string
+}
+
+// icsTime is a DATE or DATE-TIME value as written: its wall time, held in
+// UTC, and the zone it is in. UTC values have no zone.
+type icsTime struct {
+	wall   time.Time
+	zone   zone
+	allDay bool
+}
+
+func (t icsTime) instant() time.Time {
+	if t.zone == nil {
+		return t.wall
+	}
+	return t.zone.at(t.wall)
+}
+
+// icsProperty is one unfolded content line: NAME;PARAM=VALUE:value.
+type icsProperty struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// component is a BEGIN/END block with its properties and nested blocks.
+type component struct {
+	name     string
+	props    []icsProperty
+	children []*component
+}
+
+// ParseICS reads the VEVENTs of an iCalendar stream (RFC 5545), resolving
+// their times with the feed's VTIMEZONEs.
+func ParseICS(r io.Reader) ([]VEvent, error) {
+	props, err := readProperties(r)
+	if err != nil {
+		return nil, err
+	}
+	root, err := buildComponents(props)
+	if err != nil {
+		return nil, err
+	}
+
+	var calendars []*component
+	for _, c := range root.children {
+		if c.name == "VCALENDAR" {
+			calendars = append(calendars, c)
+		}
+	}
+	zones := make(map[string]*vtimezone)
+	for _, cal := range calendars {
+		for _, c := range cal.children {
+			if c.name != "VTIMEZONE" {
+				continue
+			}
+			z, err := parseVTimezone(c)
+			if err != nil {
+				return nil, err
+			}
+			zones[z.id] = z
+		}
+	}
+
+	var events []VEvent
+	for _, cal := range calendars {
+		for _, c := range cal.children {
+			if c.name != "VEVENT" {
+				continue
+			}
+			e, err := parseVEvent(c.props, zones)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+// buildComponents nests the properties into the components their BEGIN and
+// END lines mark out.
+func buildComponents(props []icsProperty) (*component, error) {
+	root := &component{}
+	stack := []*component{root}
+	for _, p := range props {
+		top := stack[len(stack)-1]
+		switch p.Name {
+		case "BEGIN":
+			c := &component{name: strings.ToUpper(p.Value)}
+			top.children = append(top.children, c)
+			stack = append(stack, c)
+		case "END":
+			if len(stack) == 1 || !strings.EqualFold(p.Value, top.name) {
+				return nil, fmt.Errorf("unexpected END:%s", p.Value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			top.props = append(top.props, p)
+		}
+	}
+	if len(stack) > 1 {
+		return nil, fmt.Errorf("%s is not closed", stack[len(stack)-1].name)
+	}
+	return root, nil
+}
+
+// readProperties unfolds the content lines of r and splits them into
+// properties.
+func readProperties(r io.Reader) ([]icsProperty, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	props := make([]icsProperty, 0, len(lines))
+	for _, line := range lines {
+		p, ok := parseProperty(line)
+		if !ok {
+			return nil, fmt.Errorf("malformed ICS line %q", line)
+		}
+		props = append(props, p)
+	}
+	return props, nil
+}
+
+func parseProperty(line string) (icsProperty, bool) {
+	// The value starts at the first colon outside a quoted parameter.
+	quoted := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return icsProperty{}, false
+	}
+	p := icsProperty{Value: line[colon+1:], Params: map[string]string{}}
+	parts := strings.Split(line[:colon], ";")
+	p.Name = strings.ToUpper(parts[0])
+	for _, param := range parts[1:] {
+		k, v, _ := strings.Cut(param, "=")
+		p.Params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+	}
+	return p, true
+}
+
+func parseVEvent(props []icsProperty, zones map[string]*vtimezone) (VEvent, error) {
+	var e VEvent
+	var end icsTime
+	var duration time.Duration
+	var hasStart, hasEnd, hasDuration bool
+	for _, p := range props {
+		var err error
+		switch p.Name {
+		case "UID":
+			e.UID = p.Value
+		case "SUMMARY":
+			e.Summary = unescapeText(p.Value)
+		case "DESCRIPTION":
+			e.Description = unescapeText(p.Value)
+		case "STATUS":
+			e.Status = strings.ToUpper(p.Value)
+		case "LOCATION":
+			e.Location = unescapeText(p.Value)
+		case "ORGANIZER":
+			e.Organizer = mailAddress(p.Value)
+		case "ATTENDEE":
+			e.Attendees = append(e.Attendees, Attendee{
+				Email:    mailAddress(p.Value),
+				Name:     p.Params["CN"],
+				PartStat: strings.ToUpper(p.Params["PARTSTAT"]),
+			})
+		case "X-GOOGLE-CONFERENCE", "X-MICROSOFT-SKYPETEAMSMEETINGURL", "CONFERENCE":
+			if e.ConferenceURL == "" {
+				e.ConferenceURL = p.Value
+			}
+		case "DTSTART":
+			e.start, err = parseICSTime(p, zones)
+			hasStart = true
+		case "DTEND":
+			end, err = parseICSTime(p, zones)
+			hasEnd = true
+		case "DURATION":
+			duration, err = parseDuration(p.Value)
+			hasDuration = true
+		case "RRULE":
+			e.RRule = p.Value
+		case "RDATE", "EXDATE":
+			var times []time.Time
+			times, err = parseICSTimes(p, zones)
+			if p.Name == "RDATE" {
+				e.RDates = append(e.RDates, times...)
+			} else {
+				e.ExDates = append(e.ExDates, times...)
+			}
+		case "RECURRENCE-ID":
+			var id icsTime
+			id, err = parseICSTime(p, zones)
+			e.RecurrenceID = id.instant()
+		}
+		if err != nil {
+			return e, fmt.Errorf("event %q: %s: %w", e.UID, p.Name, err)
+		}
+	}
+	if !hasStart {
+		return e, fmt.Errorf("event %q has no DTSTART", e.UID)
+	}
+	e.Start, e.AllDay = e.start.instant(), e.start.allDay
+	if e.ConferenceURL == "" {
+		e.ConferenceURL = meetingLink.FindString(e.Location + "\n" + e.Description)
+	}
+	switch {
+	case hasEnd:
+		e.End = end.instant()
+	case hasDuration:
+		e.End = e.Start.Add(duration)
+	case e.AllDay:
+		e.End = e.Start.AddDate(0, 0, 1)
+	default:
+		e.End = e.Start
+	}
+	if e.RRule != "" {
+		// A rule using parts we cannot expand leaves just the first
+		// occurrence rather than failing the whole feed.
+		if rule, err := parseRRule(e.RRule); err == nil {
+			e.rule = rule
+		}
+	}
+	if e.rule != nil && e.rule.until != "" {
+		until, err := parseICSValue(e.rule.until, nil, zones)
+		if err != nil {
+			return e, fmt.Errorf("event %q: RRULE UNTIL: %w", e.UID, err)
+		}
+		if until.zone != nil {
+			// Floating and date values are in the zone of DTSTART.
+			until.zone = e.start.zone
+		}
+		e.until = until.instant()
+		if until.allDay && !e.AllDay {
+			e.until = e.until.AddDate(0, 0, 1).Add(-time.Second)
+		}
+	}
+	return e, nil
+}
+
+// parseICSTime reads a DATE or DATE-TIME value. UTC times end in Z, TZID
+// names the zone of local times, and times with neither float in the local
+// zone.
+func parseICSTime(p icsProperty, zones map[string]*vtimezone) (icsTime, error) {
+	return parseICSValue(p.Value, p.Params, zones)
+}
+
+// parseICSTimes reads the comma-separated list of an RDATE or EXDATE.
+func parseICSTimes(p icsProperty, zones map[string]*vtimezone) ([]time.Time, error) {
+	var times []time.Time
+	for _, v := range strings.Split(p.Value, ",") {
+		if p.Params["VALUE"] == "PERIOD" {
+			v, _, _ = strings.Cut(v, "/")
+		}
+		t, err := parseICSValue(v, p.Params, zones)
+		if err != nil {
+			return nil, err
+		}
+		times = append(times, t.instant())
+	}
+	return times, nil
+}
+
+func parseICSValue(value string, params map[string]string, zones map[string]*vtimezone) (icsTime, error) {
+	if params["VALUE"] == "DATE" || len(value) == len("20060102") {
+		t, err := time.Parse("20060102", value)
+		return icsTime{wall: t, zone: locationZone{time.Local}, allDay: true}, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		return icsTime{wall: t}, err
+	}
+	t, err := time.Parse("20060102T150405", value)
+	return icsTime{wall: t, zone: lookupZone(params["TZID"], zones)}, err
+}
+
+var durationPattern = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// parseDuration reads an RFC 5545 DURATION such as PT1H30M or P1D.
+func parseDuration(s string) (time.Duration, error) {
+	m := durationPattern.FindStringSubmatch(s)
+	if m == nil || s == "P" || strings.HasSuffix(s, "T") {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	for i, unit := range units {
+		if m[i+2] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(m[i+2])
+		if err != nil {
+			return 0, err
+		}
+		d += time.Duration(n) * unit
+	}
+	if m[1] == "-" {
+		d = -d
+	}
+	return d, nil
+}
+
+// meetingLink finds links to the common video meeting services.
+var meetingLink = regexp.MustCompile(`https://(?:meet\.google\.com|[\w.-]*zoom\.us/j|teams\.microsoft\.com/l/meetup-join|[\w.-]*webex\.com/(?:meet|join))/[^\s<>"]*`)
+
+func mailAddress(value string) string {
+	if len(value) > len("mailto:") && strings.EqualFold(value[:len("mailto:")], "mailto:") {
+		value = value[len("mailto:"):]
+	}
+	return strings.ToLower(value)
+}
+
+var textEscapes = strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`)
+
+func unescapeText(s string) string {
+	return textEscapes.Replace(s)
+}
+
+// Expand returns the occurrences of events that overlap [from, to), in
+// start order. A recurring event gives an occurrence for each start of its
+// RRULE and RDATEs, except its EXDATEs and the starts an override replaces;
+// the overrides themselves are occurrences like any single event.
+func Expand(events []VEvent, from, to time.Time) []VEvent {
+	overridden := make(map[string]bool)
+	for _, e := range events {
+		if !e.RecurrenceID.IsZero() {
+			overridden[occurrenceKey(e.UID, e.RecurrenceID)] = true
+		}
+	}
+
+	var out []VEvent
+	for _, e := range events {
+		if e.rule == nil && len(e.RDates) == 0 || !e.RecurrenceID.IsZero() {
+			if overlaps(e, from, to) {
+				out = append(out, e)
+			}
+			continue
+		}
+
+		seen := make(map[string]bool)
+		add := func(start time.Time) {
+			key := occurrenceKey(e.UID, start)
+			if seen[key] || overridden[key] || slices.ContainsFunc(e.ExDates, start.Equal) {
+				return
+			}
+			seen[key] = true
+			o := e
+			o.Start, o.End, o.RecurrenceID = start, occurrenceEnd(e, start), start
+			if overlaps(o, from, to) {
+				out = append(out, o)
+			}
+		}
+		if e.rule != nil {
+			e.rule.each(e.start.wall, func(wall time.Time) bool {
+				start := icsTime{wall: wall, zone: e.start.zone}.instant()
+				if !e.until.IsZero() && start.After(e.until) {
+					return false
+				}
+				if e.AllDay && !sameDate(start, from.Location()).Before(to) || !e.AllDay && !start.Before(to) {
+					return false
+				}
+				add(start)
+				return true
+			})
+		} else {
+			// DTSTART is the first instance; without a rule to produce
+			// it, only the RDATEs would be left.
+			add(e.Start)
+		}
+		for _, start := range e.RDates {
+			add(start)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out
+}
+
+func occurrenceKey(uid string, start time.Time) string {
+	return uid + "@" + strconv.FormatInt(start.Unix(), 10)
+}
+
+// occurrenceEnd keeps the series' length. All-day events keep their number
+// of days, which a daylight saving change would otherwise shift.
+func occurrenceEnd(e VEvent, start time.Time) time.Time {
+	if e.AllDay {
+		days := int(math.Round(e.End.Sub(e.Start).Hours() / 24))
+		return start.AddDate(0, 0, days)
+	}
+	return start.Add(e.End.Sub(e.Start))
+}
+
+// overlaps reports whether e falls in [from, to). Dates have no zone; they
+// cover the same days wherever from is.
+func overlaps(e VEvent, from, to time.Time) bool {
+	start, end := e.Start, e.End
+	if e.AllDay {
+		start = sameDate(start, from.Location())
+		end = sameDate(end, from.Location())
+	}
+	if !end.After(start) {
+		// An instant still happens at its start.
+		end = start.Add(time.Nanosecond)
+	}
+	return start.Before(to) && end.After(from)
+}
+
+// eventsBetween converts the occurrences in [from, to) to Events. self
+// lists our own addresses, which pick out our attendee entry. Cancelled and
+// declined events are dropped.
+func eventsBetween(vevents []VEvent, from, to time.Time, self []string) []Event {
+	var events []Event
+	for _, v := range Expand(vevents, from, to) {
+		if v.Status == "CANCELLED" {
+			continue
+		}
+		e := Event{
+			UID:           v.UID,
+			Name:          v.Summary,
+			Description:   v.Description,
+			Start:         v.Start.In(from.Location()),
+			End:           v.End.In(from.Location()),
+			AllDay:        v.AllDay,
+			Type:          "event",
+			Attendees:     len(v.Attendees),
+			Organizer:     v.Organizer != "" && slices.Contains(lowered(self), v.Organizer),
+			Location:      v.Location,
+			ConferenceURL: v.ConferenceURL,
+		}
+		if v.AllDay {
+			e.Start, e.End = sameDate(v.Start, from.Location()), sameDate(v.End, from.Location())
+		}
+		if v.rule != nil || !v.RecurrenceID.IsZero() {
+			e.SeriesID = v.UID
+		}
+		for _, a := range v.Attendees {
+			if slices.Contains(lowered(self), a.Email) {
+				e.Response = partStatResponse(a.PartStat)
+			}
+		}
+		if e.Response == ResponseDeclined {
+			continue
+		}
+		events = append(events, e)
+	}
+	return events
+}
+
+func partStatResponse(partStat string) ResponseStatus {
+	switch partStat {
+	case "ACCEPTED":
+		return ResponseAccepted
+	case "TENTATIVE":
+		return ResponseTentative
+	case "DECLINED":
+		return ResponseDeclined
+	case "NEEDS-ACTION", "":
+		return ResponseNeedsAction
+	}
+	return ResponseNone
+}
+
+func lowered(addresses []string) []string {
+	out := make([]string, len(addresses))
+	for i, a := range addresses {
+		out[i] = strings.ToLower(a)
+	}
+	return out
+}
+
+func sameDate(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
@@ -0,0 +1,359 @@
+package calendar
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseICS(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "work.ics"))
+	if err != nil {
+		t.Fatalf("Failed to open fixture: %v", err)
+	}
+	defer f.Close()
+
+	events, err := ParseICS(f)
+	if err != nil {
+		t.Fatalf("Failed to parse ICS: %v", err)
+	}
+	if len(events) != 5 {
+		t.Fatalf("Expected 5 events, got %d", len(events))
+	}
+
+	standup := events[0]
+	if standup.UID != "standup-1@example.com" {
+		t.Errorf("Expected UID standup-1@example.com, got %s", standup.UID)
+	}
+	if standup.Description != "Yesterday, today, blockers.\nKeep it short." {
+		t.Errorf("Expected unescaped description, got %q", standup.Description)
+	}
+	if !standup.Start.Equal(time.Date(2023, 10, 27, 9, 30, 0, 0, time.UTC)) {
+		t.Errorf("Expected start 09:30 UTC, got %v", standup.Start)
+	}
+
+	planning := events[1]
+	if planning.Summary != "Sprint Planning with a title long enough that the server folds the line" {
+		t.Errorf("Expected the folded summary joined, got %q", planning.Summary)
+	}
+	// 11:00 in New York is 15:00 UTC at the end of October.
+	if !planning.Start.Equal(time.Date(2023, 10, 27, 15, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected start 15:00 UTC, got %v", planning.Start.UTC())
+	}
+	if planning.End.Sub(planning.Start) != 90*time.Minute {
+		t.Errorf("Expected a 90 minute duration, got %v", planning.End.Sub(planning.Start))
+	}
+
+	if !events[2].AllDay {
+		t.Error("Expected the offsite to be all day")
+	}
+	if events[3].Status != "CANCELLED" {
+		t.Errorf("Expected CANCELLED, got %q", events[3].Status)
+	}
+}
+
+func TestParseICS_Malformed(t *testing.T) {
+	input := "BEGIN:VCALENDAR\nBEGIN:VEVENT\nno colon here\nEND:VEVENT\nEND:VCALENDAR\n"
+	if _, err := ParseICS(strings.NewReader(input)); err == nil {
+		t.Error("Expected error for a malformed line, got nil")
+	}
+
+	input = "BEGIN:VCALENDAR\nBEGIN:VEVENT\nUID:x\nEND:VEVENT\nEND:VCALENDAR\n"
+	if _, err := ParseICS(strings.NewReader(input)); err == nil {
+		t.Error("Expected error for an event without DTSTART, got nil")
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"PT1H30M": 90 * time.Minute,
+		"P1D":     24 * time.Hour,
+		"P1W":     7 * 24 * time.Hour,
+		"-PT15M":  -15 * time.Minute,
+		"P1DT2S":  24*time.Hour + 2*time.Second,
+	}
+	for in, want := range tests {
+		got, err := parseDuration(in)
+		if err != nil {
+			t.Errorf("parseDuration(%q): unexpected error %v", in, err)
+		} else if got != want {
+			t.Errorf("parseDuration(%q): expected %v, got %v", in, want, got)
+		}
+	}
+	for _, in := range []string{"", "P", "PT", "1H", "PT1X"} {
+		if _, err := parseDuration(in); err == nil {
+			t.Errorf("parseDuration(%q): expected error, got nil", in)
+		}
+	}
+}
+
+func TestICSCalendar_EventsBetween(t *testing.T) {
+	feed, err := os.ReadFile(filepath.Join("testdata", "work.ics"))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/private-secret/basic.ics" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/calendar")
+		w.Write(feed)
+	}))
+	defer server.Close()
+
+	cal := NewICS(server.URL + "/private-secret/basic.ics")
+	day := time.Date(2023, 10, 27, 0, 0, 0, 0, time.UTC)
+	events, err := cal.EventsBetween(day, day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("Failed to get events: %v", err)
+	}
+
+	var names []string
+	for _, e := range events {
+		names = append(names, e.Name)
+	}
+	want := []string{"Team Offsite", "Daily Standup", "Sprint Planning with a title long enough that the server folds the line"}
+	if strings.Join(names, "|") != strings.Join(want, "|") {
+		t.Errorf("Expected %v, got %v", want, names)
+	}
+	for _, e := range events {
+		if e.Name == "Daily Standup" && (e.Start.Format(time.RFC3339) != "2023-10-27T09:30:00Z" || e.End.Format(time.RFC3339) != "2023-10-27T10:00:00Z") {
+			t.Errorf("Expected times in the day's zone, got %v to %v", e.Start, e.End)
+		}
+		if e.Name == "Team Offsite" && (!e.AllDay || e.Start.Format(time.DateOnly) != "2023-10-27" || e.End.Format(time.DateOnly) != "2023-10-28") {
+			t.Errorf("Expected all-day dates, got %v to %v", e.Start, e.End)
+		}
+	}
+}
+
+func TestICSCalendar_HTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	_, err := NewICS(server.URL+"/private-secret/basic.ics").EventsBetween(time.Now(), time.Now().Add(time.Hour))
+	if err == nil {
+		t.Fatal("Expected error for a 404 feed, got nil")
+	}
+	if strings.Contains(err.Error(), "private-secret") {
+		t.Errorf("Expected the feed URL to stay out of the error, got %v", err)
+	}
+}
+
+func TestICSCalendar_LocalFile(t *testing.T) {
+	cal := NewICS(filepath.Join("testdata", "work.ics"))
+	day := time.Date(2023, 10, 30, 0, 0, 0, 0, time.UTC)
+	events, err := cal.EventsBetween(day, day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("Failed to get events: %v", err)
+	}
+	if len(events) != 1 || events[0].Name != "Next Week" {
+		t.Errorf("Expected only Next Week, got %v", events)
+	}
+}
+
+// dayEvents lists the events of a fixture on a UTC day as "Name@HH:MM",
+// or just the name for all-day events.
+func dayEvents(t *testing.T, fixture string, day string) []string {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatalf("Failed to open fixture: %v", err)
+	}
+	defer f.Close()
+	vevents, err := ParseICS(f)
+	if err != nil {
+		t.Fatalf("Failed to parse %s: %v", fixture, err)
+	}
+	from, err := time.Parse(time.DateOnly, day)
+	if err != nil {
+		t.Fatalf("Bad day %s: %v", day, err)
+	}
+
+	var got []string
+	for _, e := range eventsBetween(vevents, from, from.Add(24*time.Hour), nil) {
+		if e.AllDay {
+			got = append(got, e.Name)
+		} else {
+			got = append(got, e.Name+"@"+e.Start.Format("15:04"))
+		}
+	}
+	return got
+}
+
+func TestExpand_Corpus(t *testing.T) {
+	tests := []struct {
+		fixture string
+		day     string
+		want    []string
+	}{
+		// Google: a weekday series in Los Angeles across the start of
+		// daylight saving on March 12.
+		{"google-recurring.ics", "2023-03-10", []string{"Standup@17:30"}},
+		{"google-recurring.ics", "2023-03-13", []string{"Standup@16:30"}},
+		// Excluded, and the last of three monthly reviews.
+		{"google-recurring.ics", "2023-03-14", []string{"Architecture Review@21:00"}},
+		{"google-recurring.ics", "2023-04-11", []string{"Standup@16:30"}},
+		// Moved later the same day.
+		{"google-recurring.ics", "2023-03-15", []string{"Standup (late)@18:00", "Demo@20:00"}},
+		// A cancelled occurrence.
+		{"google-recurring.ics", "2023-03-16", nil},
+		{"google-recurring.ics", "2023-03-17", []string{"Company Anniversary", "Standup@16:30"}},
+		// Monday's occurrence moved to the Saturday before.
+		{"google-recurring.ics", "2023-03-18", []string{"Standup (weekend)@17:00"}},
+		{"google-recurring.ics", "2023-03-20", nil},
+		// The second value of a multi-valued EXDATE.
+		{"google-recurring.ics", "2023-03-22", nil},
+		{"google-recurring.ics", "2023-03-29", []string{"Standup@16:30", "Demo@20:00"}},
+		{"google-recurring.ics", "2023-04-12", []string{"Standup@16:30"}},
+
+		// Outlook: Windows zone names, across the end of daylight saving
+		// on November 5.
+		{"outlook-recurring.ics", "2023-10-27", []string{"Team Sync@13:00"}},
+		{"outlook-recurring.ics", "2023-10-31", []string{"Month-end 1:1@19:00"}},
+		{"outlook-recurring.ics", "2023-11-10", []string{"Team Sync@14:00"}},
+		{"outlook-recurring.ics", "2023-11-17", nil},
+		{"outlook-recurring.ics", "2023-11-22", []string{"Team Sync@15:00"}},
+		{"outlook-recurring.ics", "2023-11-24", []string{"Thanksgiving"}},
+		{"outlook-recurring.ics", "2023-11-30", []string{"Month-end 1:1@20:00"}},
+		// UNTIL is inclusive.
+		{"outlook-recurring.ics", "2023-12-01", []string{"Team Sync@14:00"}},
+		{"outlook-recurring.ics", "2023-12-08", nil},
+
+		// Outlook: a zone only the feed's VTIMEZONE defines, across the
+		// end of summer time on October 29.
+		{"outlook-custom-tz.ics", "2023-10-23", []string{"Weekly Review@08:00"}},
+		{"outlook-custom-tz.ics", "2023-10-30", []string{"Weekly Review@09:00"}},
+		{"outlook-custom-tz.ics", "2023-11-06", []string{"Weekly Review@09:00"}},
+		{"outlook-custom-tz.ics", "2023-11-13", nil},
+	}
+	for _, tt := range tests {
+		got := dayEvents(t, tt.fixture, tt.day)
+		if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+			t.Errorf("%s on %s: expected %v, got %v", tt.fixture, tt.day, tt.want, got)
+		}
+	}
+}
+
+func TestExpand_OccurrenceIDs(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "google-recurring.ics"))
+	if err != nil {
+		t.Fatalf("Failed to open fixture: %v", err)
+	}
+	defer f.Close()
+	vevents, err := ParseICS(f)
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	from := time.Date(2023, 3, 13, 0, 0, 0, 0, time.UTC)
+	occurrences := Expand(vevents, from, from.AddDate(0, 0, 5))
+	seen := make(map[string]bool)
+	for _, o := range occurrences {
+		if o.RecurrenceID.IsZero() {
+			continue
+		}
+		key := o.UID + o.RecurrenceID.String()
+		if seen[key] {
+			t.Errorf("Expected one occurrence per recurrence ID, got %s twice", key)
+		}
+		seen[key] = true
+	}
+	if len(seen) == 0 {
+		t.Error("Expected occurrences to carry their recurrence IDs")
+	}
+}
+
+func TestExpand_RDatesWithoutRule(t *testing.T) {
+	feed := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\nUID:rdates\r\nSUMMARY:Workshop\r\n" +
+		"DTSTART:20240304T100000Z\r\nDTEND:20240304T110000Z\r\n" +
+		"RDATE:20240306T100000Z\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:bad-rule\r\nSUMMARY:Office Hours\r\n" +
+		"DTSTART:20240305T140000Z\r\nDTEND:20240305T150000Z\r\n" +
+		"RRULE:FREQ=FORTNIGHTLY\r\nRDATE:20240307T140000Z\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+	vevents, err := ParseICS(strings.NewReader(feed))
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	from := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	var got []string
+	for _, o := range Expand(vevents, from, from.AddDate(0, 0, 7)) {
+		got = append(got, o.Summary+"@"+o.Start.UTC().Format("Jan 2"))
+	}
+	want := "Workshop@Mar 4, Office Hours@Mar 5, Workshop@Mar 6, Office Hours@Mar 7"
+	if strings.Join(got, ", ") != want {
+		t.Errorf("Expected %s, got %v", want, got)
+	}
+}
+
+func TestLookupZone(t *testing.T) {
+	wall := time.Date(2023, 7, 1, 12, 0, 0, 0, time.UTC)
+	tests := map[string]string{
+		"America/New_York":                        "16:00",
+		"Eastern Standard Time":                   "16:00",
+		"/mozilla.org/20050126_1/America/Chicago": "17:00",
+		"Tokyo Standard Time":                     "03:00",
+	}
+	for tzid, want := range tests {
+		if got := lookupZone(tzid, nil).at(wall).UTC().Format("15:04"); got != want {
+			t.Errorf("%s: expected noon at %s UTC, got %s", tzid, want, got)
+		}
+	}
+}
+
+func TestParseOffset(t *testing.T) {
+	tests := map[string]int{"-0500": -5 * 3600, "+0530": 5*3600 + 1800, "+013045": 3600 + 1800 + 45}
+	for in, want := range tests {
+		got, err := parseOffset(in)
+		if err != nil || got != want {
+			t.Errorf("parseOffset(%q): expected %d, got %d (%v)", in, want, got, err)
+		}
+	}
+	for _, in := range []string{"0500", "+5", "-05:00"} {
+		if _, err := parseOffset(in); err == nil {
+			t.Errorf("parseOffset(%q): expected error, got nil", in)
+		}
+	}
+}
+
+func TestICSCalendar_Invitations(t *testing.T) {
+	cal := NewICS(filepath.Join("testdata", "invitations.ics"))
+	cal.Emails = []string{"ME@example.com"}
+	day := time.Date(2023, 10, 27, 0, 0, 0, 0, time.UTC)
+	events, err := cal.EventsBetween(day, day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("Failed to get events: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("Expected the declined event to be dropped, got %v", events)
+	}
+
+	review := events[0]
+	if !review.Organizer || review.Response != ResponseAccepted || review.Attendees != 3 {
+		t.Errorf("Expected an accepted event we organise with 3 attendees, got %+v", review)
+	}
+	if review.Location != "Room 4" || review.ConferenceURL != "https://teams.microsoft.com/l/meetup-join/19%3ameeting_abc" {
+		t.Errorf("Expected the location and Teams link, got %q and %q", review.Location, review.ConferenceURL)
+	}
+
+	vendor := events[1]
+	if !vendor.Tentative() || vendor.Organizer {
+		t.Errorf("Expected a tentative invitation, got %+v", vendor)
+	}
+	if vendor.ConferenceURL != "https://example.zoom.us/j/123456789?pwd=abc" {
+		t.Errorf("Expected the Zoom link from the description, got %q", vendor.ConferenceURL)
+	}
+
+	if events[2].Response != ResponseNeedsAction {
+		t.Errorf("Expected an unanswered invitation, got %q", events[2].Response)
+	}
+}
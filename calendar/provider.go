@@ -0,0 +1,53 @@
+package calendar
+
+import (
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Provider is a source of calendar events.
+type Provider interface {
+	// EventsBetween returns the events overlapping [start, end).
+	EventsBetween(start, end time.Time) ([]Event, error)
+}
+
+// Providers merges the events of several providers. An event seen through
+// more than one of them, such as a Google calendar also subscribed to as an
+// ICS feed, is kept once.
+type Providers []Provider
+
+// EventsBetween queries every provider and fails if any of them does, so a
+// broken source is not mistaken for a free day.
+func (p Providers) EventsBetween(start, end time.Time) ([]Event, error) {
+	var all []Event
+	for _, provider := range p {
+		events, err := provider.EventsBetween(start, end)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, events...)
+	}
+	return Dedupe(all), nil
+}
+
+// Dedupe drops repeats of an event, keeping the first. Events are the same
+// when they share a UID and start at the same time, which keeps the
+// occurrences of a recurring event apart. Events without a UID are always
+// kept. The result is in start order.
+func Dedupe(events []Event) []Event {
+	seen := make(map[string]bool)
+	var out []Event
+	for _, e := range events {
+		if e.UID != "" {
+			key := e.UID + "|" + strconv.FormatInt(e.Start.Unix(), 10)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+		}
+		out = append(out, e)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out
+}
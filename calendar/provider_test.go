@@ -0,0 +1,66 @@
+package calendar
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeProvider struct {
+	events []Event
+	err    error
+}
+
+func (f fakeProvider) EventsBetween(start, end time.Time) ([]Event, error) {
+	return f.events, f.err
+}
+
+func TestProviders_EventsBetween(t *testing.T) {
+	google := fakeProvider{events: []Event{
+		withUID(testEvent(t, "Sync", "2023-10-27T11:00:00-04:00", "2023-10-27T12:00:00-04:00", "event"), "sync@example.com"),
+		withUID(testEvent(t, "Standup", "2023-10-27T09:30:00-04:00", "2023-10-27T09:45:00-04:00", "event"), "standup@example.com"),
+	}}
+	ics := fakeProvider{events: []Event{
+		// The same sync seen through the ICS feed, in UTC.
+		withUID(testEvent(t, "Sync", "2023-10-27T15:00:00Z", "2023-10-27T16:00:00Z", "event"), "sync@example.com"),
+		// Another occurrence of the same series is a different event.
+		withUID(testEvent(t, "Sync", "2023-10-27T19:00:00Z", "2023-10-27T20:00:00Z", "event"), "sync@example.com"),
+		testEvent(t, "No UID", "2023-10-27T18:00:00Z", "2023-10-27T18:30:00Z", "event"),
+		withUID(testEvent(t, "Offsite", "2023-10-27", "2023-10-28", "event"), "offsite@example.com"),
+	}}
+
+	start := time.Date(2023, 10, 27, 0, 0, 0, 0, time.UTC)
+	events, err := Providers{google, ics}.EventsBetween(start, start.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var got []string
+	for _, e := range events {
+		got = append(got, e.Name+" "+e.Start.Format(time.RFC3339))
+	}
+	want := []string{
+		"Offsite " + time.Date(2023, 10, 27, 0, 0, 0, 0, time.Local).Format(time.RFC3339),
+		"Standup 2023-10-27T09:30:00-04:00",
+		"Sync 2023-10-27T11:00:00-04:00",
+		"No UID 2023-10-27T18:00:00Z",
+		"Sync 2023-10-27T19:00:00Z",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+func TestProviders_Error(t *testing.T) {
+	broken := fakeProvider{err: errors.New("feed unavailable")}
+	_, err := Providers{fakeProvider{}, broken}.EventsBetween(time.Now(), time.Now().Add(time.Hour))
+	if err == nil {
+		t.Error("Expected the provider's error, got nil")
+	}
+}
+
+func withUID(e Event, uid string) Event {
+	e.UID = uid
+	return e
+}
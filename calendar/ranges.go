@@ -0,0 +1,48 @@
+package calendar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Day returns the start and end of the day holding t.
+func Day(t time.Time) (time.Time, time.Time) {
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return start, start.AddDate(0, 0, 1)
+}
+
+// Week returns the start and end of the Monday-to-Sunday week holding t.
+func Week(t time.Time) (time.Time, time.Time) {
+	day, _ := Day(t)
+	start := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	return start, start.AddDate(0, 0, 7)
+}
+
+// ParseRange reads a window relative to now: "today", "tomorrow",
+// "yesterday", "this week", "next week" or a YYYY-MM-DD date.
+func ParseRange(s string, now time.Time) (time.Time, time.Time, error) {
+	switch strings.Join(strings.Fields(strings.ToLower(s)), " ") {
+	case "", "today":
+		start, end := Day(now)
+		return start, end, nil
+	case "tomorrow":
+		start, end := Day(now.AddDate(0, 0, 1))
+		return start, end, nil
+	case "yesterday":
+		start, end := Day(now.AddDate(0, 0, -1))
+		return start, end, nil
+	case "this week", "week":
+		start, end := Week(now)
+		return start, end, nil
+	case "next week":
+		start, end := Week(now.AddDate(0, 0, 7))
+		return start, end, nil
+	}
+	date, err := time.ParseInLocation(time.DateOnly, strings.TrimSpace(s), now.Location())
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("unknown range %q, try today, tomorrow, this week, next week or YYYY-MM-DD", s)
+	}
+	start, end := Day(date)
+	return start, end, nil
+}
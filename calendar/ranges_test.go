@@ -0,0 +1,42 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRange(t *testing.T) {
+	now := time.Date(2024, 3, 6, 15, 30, 0, 0, time.UTC) // a Wednesday
+	tests := []struct {
+		in         string
+		start, end string
+	}{
+		{"", "2024-03-06", "2024-03-07"},
+		{"today", "2024-03-06", "2024-03-07"},
+		{"Tomorrow", "2024-03-07", "2024-03-08"},
+		{"this  week", "2024-03-04", "2024-03-11"},
+		{"next week", "2024-03-11", "2024-03-18"},
+		{"2024-03-15", "2024-03-15", "2024-03-16"},
+	}
+	for _, tt := range tests {
+		start, end, err := ParseRange(tt.in, now)
+		if err != nil {
+			t.Errorf("%q: %v", tt.in, err)
+			continue
+		}
+		if start.Format(time.DateOnly) != tt.start || end.Format(time.DateOnly) != tt.end {
+			t.Errorf("%q: expected %s to %s, got %s to %s", tt.in, tt.start, tt.end, start, end)
+		}
+	}
+
+	if _, _, err := ParseRange("someday", now); err == nil {
+		t.Error("Expected an error for an unknown range")
+	}
+}
+
+func TestWeek_Sunday(t *testing.T) {
+	start, _ := Week(time.Date(2024, 3, 10, 9, 0, 0, 0, time.UTC))
+	if start.Format(time.DateOnly) != "2024-03-04" {
+		t.Errorf("Expected Sunday to belong to the week from Monday 2024-03-04, got %s", start)
+	}
+}
@@ -0,0 +1,335 @@
+package calendar
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRecurrenceYear stops rules that never match, such as the 30th of
+// February, from being searched forever.
+const maxRecurrenceYear = 2200
+
+// weekdayNum is a BYDAY entry: a weekday, optionally the nth of the month
+// or year (negative counts from the end).
+type weekdayNum struct {
+	n   int
+	day time.Weekday
+}
+
+// recurrence is a parsed RRULE. It works on wall times held in UTC, so
+// daylight saving changes never move an occurrence; the event's zone turns
+// each wall time into an instant.
+type recurrence struct {
+	freq       string
+	interval   int
+	count      int
+	until      string
+	byDay      []weekdayNum
+	byMonthDay []int
+	byMonth    []time.Month
+	bySetPos   []int
+	wkst       time.Weekday
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// parseRRule reads an RRULE value. Rule parts that calendar feeds do not
+// use for meetings, like BYHOUR or BYWEEKNO, are reported as unsupported.
+func parseRRule(value string) (*recurrence, error) {
+	r := &recurrence{interval: 1, wkst: time.Monday}
+	for _, part := range strings.Split(value, ";") {
+		key, val, _ := strings.Cut(part, "=")
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			r.freq = strings.ToUpper(val)
+		case "INTERVAL":
+			r.interval, err = strconv.Atoi(val)
+			if err == nil && r.interval < 1 {
+				err = fmt.Errorf("interval %d", r.interval)
+			}
+		case "COUNT":
+			r.count, err = strconv.Atoi(val)
+		case "UNTIL":
+			r.until = val
+		case "BYDAY":
+			for _, d := range strings.Split(val, ",") {
+				var wd weekdayNum
+				wd, err = parseWeekdayNum(d)
+				if err != nil {
+					break
+				}
+				r.byDay = append(r.byDay, wd)
+			}
+		case "BYMONTHDAY":
+			r.byMonthDay, err = parseInts(val, 31)
+		case "BYMONTH":
+			var months []int
+			months, err = parseInts(val, 12)
+			for _, m := range months {
+				if m < 0 {
+					err = fmt.Errorf("month %d", m)
+				}
+				r.byMonth = append(r.byMonth, time.Month(m))
+			}
+		case "BYSETPOS":
+			r.bySetPos, err = parseInts(val, 366)
+		case "WKST":
+			day, ok := weekdays[strings.ToUpper(val)]
+			if !ok {
+				err = fmt.Errorf("weekday %q", val)
+			}
+			r.wkst = day
+		default:
+			return nil, fmt.Errorf("unsupported RRULE part %s", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("RRULE %s: %w", key, err)
+		}
+	}
+	switch r.freq {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+	case "":
+		return nil, fmt.Errorf("RRULE without FREQ")
+	default:
+		return nil, fmt.Errorf("unsupported RRULE frequency %s", r.freq)
+	}
+	return r, nil
+}
+
+func parseWeekdayNum(s string) (weekdayNum, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if len(s) < 2 {
+		return weekdayNum{}, fmt.Errorf("weekday %q", s)
+	}
+	day, ok := weekdays[s[len(s)-2:]]
+	if !ok {
+		return weekdayNum{}, fmt.Errorf("weekday %q", s)
+	}
+	wd := weekdayNum{day: day}
+	if n := s[:len(s)-2]; n != "" {
+		var err error
+		wd.n, err = strconv.Atoi(n)
+		if err != nil || wd.n == 0 || wd.n > 53 || wd.n < -53 {
+			return weekdayNum{}, fmt.Errorf("weekday %q", s)
+		}
+	}
+	return wd, nil
+}
+
+func parseInts(s string, limit int) ([]int, error) {
+	var out []int
+	for _, f := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(f))
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 || n > limit || n < -limit {
+			return nil, fmt.Errorf("value %d out of range", n)
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+// each calls fn with the wall time of every occurrence in order, starting
+// with start itself, which always counts as the first. It stops when fn
+// returns false or COUNT is reached; UNTIL is left to fn, since comparing
+// it needs the event's zone.
+func (r *recurrence) each(start time.Time, fn func(time.Time) bool) {
+	if !fn(start) {
+		return
+	}
+	emitted := 1
+	timeOfDay := start.Sub(dateOf(start))
+	for k := 0; ; k++ {
+		period := r.period(start, k)
+		if period.Year() > maxRecurrenceYear {
+			return
+		}
+		for _, day := range r.days(start, period) {
+			t := day.Add(timeOfDay)
+			if !t.After(start) {
+				continue
+			}
+			if r.count > 0 && emitted >= r.count {
+				return
+			}
+			if !fn(t) {
+				return
+			}
+			emitted++
+		}
+	}
+}
+
+// period returns the first day of the kth interval after the one holding
+// start.
+func (r *recurrence) period(start time.Time, k int) time.Time {
+	day := dateOf(start)
+	switch r.freq {
+	case "DAILY":
+		return day.AddDate(0, 0, k*r.interval)
+	case "WEEKLY":
+		offset := (int(day.Weekday()) - int(r.wkst) + 7) % 7
+		return day.AddDate(0, 0, -offset+7*k*r.interval)
+	case "MONTHLY":
+		return time.Date(day.Year(), day.Month()+time.Month(k*r.interval), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(day.Year()+k*r.interval, 1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// days returns the days in the period starting at period that match the
+// rule, in order, after BYSETPOS is applied.
+func (r *recurrence) days(start, period time.Time) []time.Time {
+	var days []time.Time
+	switch r.freq {
+	case "DAILY":
+		if r.matchesMonth(period) && r.matchesMonthDay(period) && r.matchesWeekday(period) {
+			days = append(days, period)
+		}
+	case "WEEKLY":
+		for i := range 7 {
+			day := period.AddDate(0, 0, i)
+			if !r.matchesMonth(day) {
+				continue
+			}
+			if len(r.byDay) == 0 && day.Weekday() == start.Weekday() || len(r.byDay) > 0 && r.matchesWeekday(day) {
+				days = append(days, day)
+			}
+		}
+	case "MONTHLY":
+		if r.matchesMonth(period) {
+			days = r.monthDays(start, period.Year(), period.Month())
+		}
+	case "YEARLY":
+		months := r.byMonth
+		switch {
+		case len(months) > 0:
+		case len(r.byMonthDay) > 0:
+			months = []time.Month{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+		case len(r.byDay) > 0:
+			// BYDAY alone counts weekdays through the whole year.
+			days = nthWeekdays(r.byDay, period, period.AddDate(1, 0, 0))
+		default:
+			months = []time.Month{start.Month()}
+		}
+		for _, m := range months {
+			days = append(days, r.monthDays(start, period.Year(), m)...)
+		}
+		slices.SortFunc(days, func(a, b time.Time) int { return a.Compare(b) })
+	}
+	return r.setPos(slices.CompactFunc(days, time.Time.Equal))
+}
+
+// monthDays expands BYMONTHDAY and BYDAY within a month, intersecting them
+// when both are given. With neither, the day of start is used.
+func (r *recurrence) monthDays(start time.Time, year int, month time.Month) []time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	next := first.AddDate(0, 1, 0)
+	length := next.AddDate(0, 0, -1).Day()
+
+	var byMonthDay []time.Time
+	for _, md := range r.byMonthDay {
+		if md < 0 {
+			md = length + md + 1
+		}
+		if md >= 1 && md <= length {
+			byMonthDay = append(byMonthDay, first.AddDate(0, 0, md-1))
+		}
+	}
+	switch {
+	case len(r.byDay) > 0 && len(r.byMonthDay) > 0:
+		var days []time.Time
+		for _, d := range nthWeekdays(r.byDay, first, next) {
+			if slices.ContainsFunc(byMonthDay, d.Equal) {
+				days = append(days, d)
+			}
+		}
+		return days
+	case len(r.byDay) > 0:
+		return nthWeekdays(r.byDay, first, next)
+	case len(r.byMonthDay) > 0:
+		slices.SortFunc(byMonthDay, func(a, b time.Time) int { return a.Compare(b) })
+		return byMonthDay
+	}
+	if start.Day() > length {
+		return nil
+	}
+	return []time.Time{first.AddDate(0, 0, start.Day()-1)}
+}
+
+// nthWeekdays returns the days in [from, to) matching the BYDAY entries,
+// sorted.
+func nthWeekdays(byDay []weekdayNum, from, to time.Time) []time.Time {
+	var days []time.Time
+	for _, wd := range byDay {
+		var all []time.Time
+		for d := from.AddDate(0, 0, (int(wd.day)-int(from.Weekday())+7)%7); d.Before(to); d = d.AddDate(0, 0, 7) {
+			all = append(all, d)
+		}
+		switch {
+		case wd.n == 0:
+			days = append(days, all...)
+		case wd.n > 0 && wd.n <= len(all):
+			days = append(days, all[wd.n-1])
+		case wd.n < 0 && -wd.n <= len(all):
+			days = append(days, all[len(all)+wd.n])
+		}
+	}
+	slices.SortFunc(days, func(a, b time.Time) int { return a.Compare(b) })
+	return slices.CompactFunc(days, time.Time.Equal)
+}
+
+func (r *recurrence) setPos(days []time.Time) []time.Time {
+	if len(r.bySetPos) == 0 {
+		return days
+	}
+	var out []time.Time
+	for _, pos := range r.bySetPos {
+		switch {
+		case pos > 0 && pos <= len(days):
+			out = append(out, days[pos-1])
+		case pos < 0 && -pos <= len(days):
+			out = append(out, days[len(days)+pos])
+		}
+	}
+	slices.SortFunc(out, func(a, b time.Time) int { return a.Compare(b) })
+	return slices.CompactFunc(out, time.Time.Equal)
+}
+
+func (r *recurrence) matchesMonth(day time.Time) bool {
+	return len(r.byMonth) == 0 || slices.Contains(r.byMonth, day.Month())
+}
+
+func (r *recurrence) matchesMonthDay(day time.Time) bool {
+	if len(r.byMonthDay) == 0 {
+		return true
+	}
+	length := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	for _, md := range r.byMonthDay {
+		if md == day.Day() || md < 0 && length+md+1 == day.Day() {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesWeekday checks BYDAY for daily and weekly rules, where ordinals
+// have no meaning.
+func (r *recurrence) matchesWeekday(day time.Time) bool {
+	if len(r.byDay) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(r.byDay, func(wd weekdayNum) bool { return wd.day == day.Weekday() })
+}
+
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,84 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecurrence_Each(t *testing.T) {
+	tests := []struct {
+		rule  string
+		start string
+		want  []string
+	}{
+		{"FREQ=DAILY;COUNT=3", "20230130T090000", []string{"2023-01-30", "2023-01-31", "2023-02-01"}},
+		{"FREQ=DAILY;INTERVAL=2;BYDAY=MO,WE,FR", "20230102T090000", []string{"2023-01-02", "2023-01-04", "2023-01-06", "2023-01-16"}},
+		{"FREQ=WEEKLY;BYDAY=TU,TH", "20230103T090000", []string{"2023-01-03", "2023-01-05", "2023-01-10", "2023-01-12"}},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR;WKST=SU", "20230102T090000", []string{"2023-01-02", "2023-01-06", "2023-01-16", "2023-01-20"}},
+		// The week starts on Monday by default, which changes which Sunday
+		// belongs to the skipped week.
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=SA,SU", "20230107T090000", []string{"2023-01-07", "2023-01-08", "2023-01-21", "2023-01-22"}},
+		{"FREQ=MONTHLY", "20230131T090000", []string{"2023-01-31", "2023-03-31", "2023-05-31"}},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", "20230131T090000", []string{"2023-01-31", "2023-02-28", "2023-03-31"}},
+		{"FREQ=MONTHLY;BYDAY=2TU", "20230110T090000", []string{"2023-01-10", "2023-02-14", "2023-03-14"}},
+		{"FREQ=MONTHLY;BYDAY=-1FR", "20230127T090000", []string{"2023-01-27", "2023-02-24", "2023-03-31"}},
+		{"FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1", "20230131T090000", []string{"2023-01-31", "2023-02-28", "2023-03-31", "2023-04-28"}},
+		{"FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13", "20230113T090000", []string{"2023-01-13", "2023-10-13", "2024-09-13"}},
+		{"FREQ=YEARLY", "20200229T090000", []string{"2020-02-29", "2024-02-29", "2028-02-29"}},
+		{"FREQ=YEARLY;BYMONTH=3;BYDAY=2SU", "19700308T020000", []string{"1970-03-08", "1971-03-14", "1972-03-12"}},
+		{"FREQ=YEARLY;BYDAY=20MO", "20230515T090000", []string{"2023-05-15", "2024-05-13", "2025-05-19"}},
+	}
+	for _, tt := range tests {
+		r, err := parseRRule(tt.rule)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.rule, err)
+			continue
+		}
+		start, err := time.Parse("20060102T150405", tt.start)
+		if err != nil {
+			t.Fatalf("Bad start %s: %v", tt.start, err)
+		}
+		var got []string
+		r.each(start, func(wall time.Time) bool {
+			if wall.Hour() != start.Hour() || wall.Minute() != start.Minute() {
+				t.Errorf("%s: expected the time of DTSTART, got %v", tt.rule, wall)
+			}
+			got = append(got, wall.Format(time.DateOnly))
+			return len(got) < len(tt.want)
+		})
+		if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("%s: expected %v, got %v", tt.rule, tt.want, got)
+		}
+	}
+}
+
+func TestRecurrence_NeverMatches(t *testing.T) {
+	r, err := parseRRule("FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	n := 0
+	r.each(time.Date(2023, 1, 1, 9, 0, 0, 0, time.UTC), func(time.Time) bool {
+		n++
+		return true
+	})
+	if n != 1 {
+		t.Errorf("Expected only DTSTART, got %d occurrences", n)
+	}
+}
+
+func TestParseRRule_Invalid(t *testing.T) {
+	for _, rule := range []string{
+		"",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;BYHOUR=9",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=DAILY;INTERVAL=0",
+	} {
+		if _, err := parseRRule(rule); err == nil {
+			t.Errorf("%q: expected error, got nil", rule)
+		}
+	}
+}
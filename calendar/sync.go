@@ -0,0 +1,331 @@
+package calendar
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+)
+
+const (
+	// syncLookbackDays is how far into the past the first full sync reaches.
+	// Older ranges are read live.
+	syncLookbackDays = 14
+	// syncLookaheadDays is how far into the future a full sync reaches.
+	// Later ranges are read live, and once a week of the range has passed
+	// the next sync is a full one again.
+	syncLookaheadDays = 90
+	// refreshAfter is how old the local copy gets before a read starts a
+	// background sync.
+	refreshAfter = time.Minute
+	// StaleAfter is how old the local copy gets before it should be treated
+	// as out of date, which happens when syncing keeps failing.
+	StaleAfter = 15 * time.Minute
+)
+
+// Snapshot is implemented by providers that answer from a local copy of a
+// calendar rather than asking the server each time.
+type Snapshot interface {
+	// SyncedAt is when the copy was last brought up to date, zero when it
+	// never was.
+	SyncedAt() time.Time
+}
+
+// SyncedAt returns when the events of p were last synced: the oldest of its
+// snapshots, or zero when every source is read live.
+func SyncedAt(p Provider) time.Time {
+	switch p := p.(type) {
+	case Snapshot:
+		return p.SyncedAt()
+	case Providers:
+		var oldest time.Time
+		for _, provider := range p {
+			if at := SyncedAt(provider); !at.IsZero() && (oldest.IsZero() || at.Before(oldest)) {
+				oldest = at
+			}
+		}
+		return oldest
+	}
+	return time.Time{}
+}
+
+// Err returns the problems the sources of p report through an Err method,
+// such as a failed sync or a token that could not be saved.
+func Err(p Provider) error {
+	switch p := p.(type) {
+	case interface{ Err() error }:
+		return p.Err()
+	case Providers:
+		var errs []error
+		for _, provider := range p {
+			errs = append(errs, Err(provider))
+		}
+		return errors.Join(errs...)
+	}
+	return nil
+}
+
+// SyncedCalendar serves Google Calendar events from a local copy kept in
+// Path. Reads never wait on the network once the copy exists: a copy older
+// than a minute is refreshed in the background using Google's sync tokens,
+// so only changes are fetched, and when the refresh fails the last snapshot
+// is served.
+type SyncedCalendar struct {
+	Google *GoogleCalendarIntegration
+	Path   string
+
+	syncMu sync.Mutex // held for a whole sync, so syncs never overlap
+
+	mu      sync.Mutex // guards the fields below
+	store   *eventStore
+	syncing bool
+	lastErr error
+}
+
+type eventStore struct {
+	SyncedAt  time.Time                  `json:"syncedAt"`
+	Calendars map[string]*storedCalendar `json:"calendars"`
+}
+
+// storedCalendar is one calendar's events in [From, Until), keyed by event
+// ID.
+type storedCalendar struct {
+	From      time.Time                  `json:"from"`
+	Until     time.Time                  `json:"until"`
+	SyncToken string                     `json:"syncToken"`
+	Events    map[string]*calendar.Event `json:"events"`
+}
+
+// NewSynced serves the calendars of g from a copy kept in path.
+func NewSynced(g *GoogleCalendarIntegration, path string) *SyncedCalendar {
+	return &SyncedCalendar{Google: g, Path: path}
+}
+
+// EventsBetween returns the stored events overlapping [start, end). Only
+// the first read, before anything is stored, syncs in the foreground.
+func (s *SyncedCalendar) EventsBetween(start, end time.Time) ([]Event, error) {
+	s.mu.Lock()
+	s.loadLocked()
+	complete := s.completeLocked()
+	stale := time.Since(s.store.SyncedAt) > refreshAfter
+	s.mu.Unlock()
+
+	if !complete {
+		if err := s.Sync(); err != nil {
+			return nil, err
+		}
+	} else if stale {
+		s.refresh()
+	}
+
+	s.mu.Lock()
+	var events []Event
+	live := false
+	for _, id := range s.Google.calendarIDs() {
+		stored := s.store.Calendars[id]
+		if start.Before(stored.From) || end.After(stored.Until) {
+			live = true
+			break
+		}
+		for _, e := range filterEvents(eventList(stored.Events)) {
+			if e.Start.Before(end) && e.End.After(start) {
+				events = append(events, e)
+			}
+		}
+	}
+	s.mu.Unlock()
+	if live {
+		return s.Google.EventsBetween(start, end)
+	}
+	return Dedupe(events), nil
+}
+
+// SyncedAt is when every calendar was last brought up to date.
+func (s *SyncedCalendar) SyncedAt() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loadLocked()
+	return s.store.SyncedAt
+}
+
+// Err returns why the last sync failed, or why a refreshed token could not
+// be saved, or nil when both succeeded.
+func (s *SyncedCalendar) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Google == nil {
+		return s.lastErr
+	}
+	return errors.Join(s.lastErr, s.Google.Err())
+}
+
+// Sync fetches what changed in every calendar since the last sync, or all
+// events from two weeks ago to three months ahead when a calendar has no
+// sync token, Google has expired it or the range has moved on a week, and
+// saves the copy without the events that have left the range.
+func (s *SyncedCalendar) Sync() error {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	err := s.sync()
+	s.mu.Lock()
+	s.lastErr = err
+	s.mu.Unlock()
+	return err
+}
+
+func (s *SyncedCalendar) sync() error {
+	for _, id := range s.Google.calendarIDs() {
+		from, _ := Day(time.Now().AddDate(0, 0, -syncLookbackDays))
+		until := from.AddDate(0, 0, syncLookbackDays+syncLookaheadDays)
+
+		s.mu.Lock()
+		s.loadLocked()
+		var token string
+		if stored := s.store.Calendars[id]; stored != nil && stored.Until.AddDate(0, 0, 7).After(until) {
+			token = stored.SyncToken
+		}
+		s.mu.Unlock()
+
+		changes, next, err := s.changes(id, token, from, until)
+		if token != "" && isGone(err) {
+			token = ""
+			changes, next, err = s.changes(id, "", from, until)
+		}
+		if err != nil {
+			return fmt.Errorf("calendar %s: %w", id, err)
+		}
+
+		s.mu.Lock()
+		stored := s.store.Calendars[id]
+		if token == "" || stored == nil {
+			stored = &storedCalendar{From: from, Until: until, Events: make(map[string]*calendar.Event)}
+			s.store.Calendars[id] = stored
+		}
+		stored.SyncToken = next
+		for _, e := range changes {
+			if e.Status == "cancelled" {
+				delete(stored.Events, e.Id)
+			} else {
+				stored.Events[e.Id] = e
+			}
+		}
+		stored.prune(from)
+		s.mu.Unlock()
+	}
+
+	s.mu.Lock()
+	s.store.SyncedAt = time.Now()
+	data, err := json.Marshal(s.store)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(s.Path, data, 0600)
+}
+
+// changes lists the events changed since token, or every event in
+// [from, until) when there is no token, and returns the token for the next
+// sync.
+func (s *SyncedCalendar) changes(id, token string, from, until time.Time) ([]*calendar.Event, string, error) {
+	// A sync token may not be combined with a time range or ordering, so
+	// only the full sync is bounded; prune drops what the changes bring in
+	// from outside the range.
+	call := s.Google.calendarService.Events.List(id).SingleEvents(true).MaxResults(googlePageSize)
+	if token != "" {
+		call = call.SyncToken(token)
+	} else {
+		call = call.TimeMin(from.Format(time.RFC3339)).TimeMax(until.Format(time.RFC3339))
+	}
+	var changes []*calendar.Event
+	var next string
+	err := call.Pages(context.Background(), func(page *calendar.Events) error {
+		changes = append(changes, page.Items...)
+		next = page.NextSyncToken
+		return nil
+	})
+	return changes, next, err
+}
+
+// refresh starts a background sync unless one is already running.
+func (s *SyncedCalendar) refresh() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.syncing {
+		return
+	}
+	s.syncing = true
+	go func() {
+		_ = s.Sync()
+		s.mu.Lock()
+		s.syncing = false
+		s.mu.Unlock()
+	}()
+}
+
+// loadLocked reads the saved copy the first time it is needed. A missing or
+// unreadable file leaves an empty copy, which the next read fills.
+func (s *SyncedCalendar) loadLocked() {
+	if s.store != nil {
+		return
+	}
+	s.store = &eventStore{}
+	if data, err := os.ReadFile(s.Path); err == nil {
+		_ = json.Unmarshal(data, s.store)
+	}
+	if s.store.Calendars == nil {
+		s.store.Calendars = make(map[string]*storedCalendar)
+	}
+}
+
+// completeLocked reports whether every calendar has been synced at least
+// once.
+func (s *SyncedCalendar) completeLocked() bool {
+	for _, id := range s.Google.calendarIDs() {
+		if s.store.Calendars[id] == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// prune drops the events outside [from, Until), which reads of the copy no
+// longer reach, and moves From up to from.
+func (c *storedCalendar) prune(from time.Time) {
+	if from.After(c.From) {
+		c.From = from
+	}
+	for id, e := range c.Events {
+		start, _, startErr := googleTime(e.Start)
+		end, _, endErr := googleTime(e.End)
+		if startErr != nil || endErr != nil || !end.After(c.From) || !start.Before(c.Until) {
+			delete(c.Events, id)
+		}
+	}
+}
+
+func eventList(events map[string]*calendar.Event) []*calendar.Event {
+	list := make([]*calendar.Event, 0, len(events))
+	for _, e := range events {
+		list = append(list, e)
+	}
+	return list
+}
+
+// isGone reports whether Google has expired a sync token, which calls for
+// a full sync.
+func isGone(err error) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusGone
+}
@@ -0,0 +1,207 @@
+package calendar
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/option"
+)
+
+// fakeSyncServer answers event lists like Google: a full sync without a
+// token, the changes since a known token, and 410 Gone for an expired one.
+type fakeSyncServer struct {
+	mu       sync.Mutex
+	events   []*calendar.Event
+	changes  map[string][]*calendar.Event // sync token to the changes since
+	token    string
+	requests []string
+	timeMax  string // of the last request
+}
+
+func (f *fakeSyncServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	token := r.URL.Query().Get("syncToken")
+	f.requests = append(f.requests, token)
+	f.timeMax = r.URL.Query().Get("timeMax")
+	var items []*calendar.Event
+	switch {
+	case token == "":
+		items = f.events
+	case f.changes[token] != nil:
+		items = f.changes[token]
+	default:
+		w.WriteHeader(http.StatusGone)
+		_, _ = w.Write([]byte(`{"error":{"code":410,"message":"Sync token is no longer valid"}}`))
+		return
+	}
+	_ = json.NewEncoder(w).Encode(calendar.Events{Items: items, NextSyncToken: f.token})
+}
+
+func syncedEvent(id, start string) *calendar.Event {
+	return &calendar.Event{
+		Id:        id,
+		ICalUID:   id,
+		Summary:   id,
+		Status:    "confirmed",
+		EventType: "default",
+		Start:     &calendar.EventDateTime{DateTime: start},
+		End:       &calendar.EventDateTime{DateTime: strings.Replace(start, ":00:00", ":30:00", 1)},
+	}
+}
+
+func newSyncedForTest(t *testing.T, server *httptest.Server, path string) *SyncedCalendar {
+	t.Helper()
+	svc, err := calendar.NewService(context.Background(), option.WithEndpoint(server.URL+"/"), option.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewSynced(&GoogleCalendarIntegration{calendarService: svc}, path)
+}
+
+func eventNames(events []Event) string {
+	var names []string
+	for _, e := range events {
+		names = append(names, e.Name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func TestSyncedCalendar(t *testing.T) {
+	day, end := Day(time.Now())
+	at := func(hour int) string { return day.Add(time.Duration(hour) * time.Hour).Format(time.RFC3339) }
+
+	fake := &fakeSyncServer{
+		events: []*calendar.Event{syncedEvent("standup", at(9)), syncedEvent("review", at(14))},
+		token:  "t1",
+	}
+	server := httptest.NewServer(fake)
+	path := filepath.Join(t.TempDir(), "calendar-cache.json")
+	s := newSyncedForTest(t, server, path)
+
+	events, err := s.EventsBetween(day, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eventNames(events) != "review,standup" {
+		t.Errorf("Expected the full sync to store both events, got %v", eventNames(events))
+	}
+
+	// The review is cancelled and a planning meeting added.
+	cancelled := syncedEvent("review", at(14))
+	cancelled.Status = "cancelled"
+	fake.changes = map[string][]*calendar.Event{"t1": {cancelled, syncedEvent("planning", at(11))}}
+	fake.token = "t2"
+	if err := s.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	events, _ = s.EventsBetween(day, end)
+	if eventNames(events) != "planning,standup" {
+		t.Errorf("Expected the incremental sync to apply the changes, got %v", eventNames(events))
+	}
+
+	// Google expires t2, so the next sync starts over.
+	fake.events = []*calendar.Event{syncedEvent("standup", at(9))}
+	fake.token = "t3"
+	if err := s.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	events, _ = s.EventsBetween(day, end)
+	if eventNames(events) != "standup" {
+		t.Errorf("Expected an expired token to trigger a full sync, got %v", eventNames(events))
+	}
+	if got := strings.Join(fake.requests, ","); got != ",t1,t2," {
+		t.Errorf("Expected requests without a token, with t1, with t2 and without, got %q", got)
+	}
+	syncedAt := s.SyncedAt()
+
+	// Offline, a new process serves the saved snapshot.
+	server.Close()
+	offline := newSyncedForTest(t, server, path)
+	_ = offline.Sync() // fails, the server is gone
+	events, err = offline.EventsBetween(day, end)
+	if err != nil {
+		t.Fatalf("Expected the snapshot offline, got %v", err)
+	}
+	if eventNames(events) != "standup" {
+		t.Errorf("Expected the saved events offline, got %v", eventNames(events))
+	}
+	if offline.Err() == nil {
+		t.Error("Expected the failed sync to be reported")
+	}
+	if !offline.SyncedAt().Equal(syncedAt) {
+		t.Errorf("Expected the snapshot time %v, got %v", syncedAt, offline.SyncedAt())
+	}
+}
+
+func TestSyncedCalendar_KeepsRange(t *testing.T) {
+	day, _ := Day(time.Now())
+	at := func(days int) string { return day.AddDate(0, 0, days).Add(9 * time.Hour).Format(time.RFC3339) }
+
+	fake := &fakeSyncServer{
+		events: []*calendar.Event{syncedEvent("old", at(-30)), syncedEvent("standup", at(0)), syncedEvent("far", at(200))},
+		token:  "t1",
+	}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	s := newSyncedForTest(t, server, filepath.Join(t.TempDir(), "calendar-cache.json"))
+
+	if err := s.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	want := day.AddDate(0, 0, syncLookaheadDays).Format(time.RFC3339)
+	if fake.timeMax != want {
+		t.Errorf("Expected the full sync to end at %s, got %q", want, fake.timeMax)
+	}
+	stored := s.store.Calendars["primary"]
+	if got := eventNames(filterEvents(eventList(stored.Events))); got != "standup" {
+		t.Errorf("Expected only the events in range to be stored, got %v", got)
+	}
+
+	// Changes are not bounded by time, so those outside the range are
+	// dropped after applying them.
+	fake.changes = map[string][]*calendar.Event{"t1": {syncedEvent("retro", at(-20)), syncedEvent("offsite", at(120)), syncedEvent("planning", at(1))}}
+	fake.token = "t2"
+	if err := s.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if got := eventNames(filterEvents(eventList(stored.Events))); got != "planning,standup" {
+		t.Errorf("Expected the changes outside the range to be dropped, got %v", got)
+	}
+
+	// A week on, the range has moved and the next sync starts over.
+	stored.Until = stored.Until.AddDate(0, 0, -7)
+	if err := s.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(fake.requests, ","); got != ",t1," {
+		t.Errorf("Expected a full sync once the range moved on, got requests %q", got)
+	}
+}
+
+func TestSyncedAt(t *testing.T) {
+	older := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+	p := Providers{&fakeSnapshot{newer}, NewICS("work.ics"), &fakeSnapshot{older}}
+	if got := SyncedAt(p); !got.Equal(older) {
+		t.Errorf("Expected the oldest snapshot %v, got %v", older, got)
+	}
+	if got := SyncedAt(NewICS("work.ics")); !got.IsZero() {
+		t.Errorf("Expected a live source to have no sync time, got %v", got)
+	}
+}
+
+type fakeSnapshot struct{ at time.Time }
+
+func (f *fakeSnapshot) EventsBetween(start, end time.Time) ([]Event, error) { return nil, nil }
+func (f *fakeSnapshot) SyncedAt() time.Time                                 { return f.at }
@@ -0,0 +1,214 @@
+package calendar
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// zone turns a wall time, held in UTC, into an instant.
+type zone interface {
+	at(wall time.Time) time.Time
+}
+
+type locationZone struct {
+	loc *time.Location
+}
+
+func (z locationZone) at(wall time.Time) time.Time {
+	return time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), 0, z.loc)
+}
+
+// windowsZones maps the Windows zone names Outlook and Exchange write as
+// TZID to IANA names.
+var windowsZones = map[string]string{
+	"Dateline Standard Time":          "Etc/GMT+12",
+	"Hawaiian Standard Time":          "Pacific/Honolulu",
+	"Alaskan Standard Time":           "America/Anchorage",
+	"Pacific Standard Time":           "America/Los_Angeles",
+	"US Mountain Standard Time":       "America/Phoenix",
+	"Mountain Standard Time":          "America/Denver",
+	"Central Standard Time":           "America/Chicago",
+	"Eastern Standard Time":           "America/New_York",
+	"Atlantic Standard Time":          "America/Halifax",
+	"Newfoundland Standard Time":      "America/St_Johns",
+	"E. South America Standard Time":  "America/Sao_Paulo",
+	"UTC":                             "UTC",
+	"GMT Standard Time":               "Europe/London",
+	"Greenwich Standard Time":         "Atlantic/Reykjavik",
+	"W. Europe Standard Time":         "Europe/Berlin",
+	"Central Europe Standard Time":    "Europe/Budapest",
+	"Central European Standard Time":  "Europe/Warsaw",
+	"Romance Standard Time":           "Europe/Paris",
+	"GTB Standard Time":               "Europe/Bucharest",
+	"FLE Standard Time":               "Europe/Kiev",
+	"Israel Standard Time":            "Asia/Jerusalem",
+	"Russian Standard Time":           "Europe/Moscow",
+	"Arabian Standard Time":           "Asia/Dubai",
+	"India Standard Time":             "Asia/Kolkata",
+	"China Standard Time":             "Asia/Shanghai",
+	"Singapore Standard Time":         "Asia/Singapore",
+	"Tokyo Standard Time":             "Asia/Tokyo",
+	"Korea Standard Time":             "Asia/Seoul",
+	"AUS Eastern Standard Time":       "Australia/Sydney",
+	"E. Australia Standard Time":      "Australia/Brisbane",
+	"W. Australia Standard Time":      "Australia/Perth",
+	"New Zealand Standard Time":       "Pacific/Auckland",
+	"South Africa Standard Time":      "Africa/Johannesburg",
+	"SA Pacific Standard Time":        "America/Bogota",
+	"Central Standard Time (Mexico)":  "America/Mexico_City",
+	"Argentina Standard Time":         "America/Buenos_Aires",
+	"Pacific SA Standard Time":        "America/Santiago",
+	"W. Central Africa Standard Time": "Africa/Lagos",
+}
+
+// lookupZone resolves a TZID. IANA names are trusted over the feed's own
+// VTIMEZONE since Go's database is more complete; Windows names are mapped;
+// other names, like Outlook's "(UTC-05:00) Eastern Time (US & Canada)", use
+// the feed's definition. Unknown zones float in the local zone.
+func lookupZone(tzid string, defined map[string]*vtimezone) zone {
+	if loc, ok := loadLocation(tzid); ok {
+		return locationZone{loc}
+	}
+	if name, ok := windowsZones[tzid]; ok {
+		if loc, err := time.LoadLocation(name); err == nil {
+			return locationZone{loc}
+		}
+	}
+	if z, ok := defined[tzid]; ok {
+		return z
+	}
+	return locationZone{time.Local}
+}
+
+// loadLocation tries tzid as an IANA name, also after dropping the prefixes
+// some exporters add, as in /mozilla.org/20050126_1/America/New_York.
+func loadLocation(tzid string) (*time.Location, bool) {
+	if tzid == "" || tzid == "Local" {
+		return nil, false
+	}
+	parts := strings.Split(strings.Trim(tzid, "/"), "/")
+	for i := range parts {
+		if loc, err := time.LoadLocation(strings.Join(parts[i:], "/")); err == nil {
+			return loc, true
+		}
+	}
+	return nil, false
+}
+
+// transition is an onset of a VTIMEZONE observance.
+type transition struct {
+	wall   time.Time
+	offset int
+	name   string
+}
+
+// vtimezone is a zone defined in the feed by STANDARD and DAYLIGHT
+// observances.
+type vtimezone struct {
+	id          string
+	transitions []transition
+	// initial is the offset before the first transition.
+	initial int
+}
+
+func (z *vtimezone) at(wall time.Time) time.Time {
+	offset, name := z.initial, z.id
+	i := sort.Search(len(z.transitions), func(i int) bool { return z.transitions[i].wall.After(wall) })
+	if i > 0 {
+		offset, name = z.transitions[i-1].offset, z.transitions[i-1].name
+	}
+	return time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), 0,
+		time.FixedZone(name, offset)).UTC()
+}
+
+// parseVTimezone builds a zone from a VTIMEZONE component, expanding each
+// observance's onsets up to maxRecurrenceYear.
+func parseVTimezone(c *component) (*vtimezone, error) {
+	z := &vtimezone{}
+	first := time.Time{}
+	for _, p := range c.props {
+		if p.Name == "TZID" {
+			z.id = unescapeText(p.Value)
+		}
+	}
+	for _, obs := range c.children {
+		if obs.name != "STANDARD" && obs.name != "DAYLIGHT" {
+			continue
+		}
+		var start time.Time
+		var from, to int
+		var rule *recurrence
+		var rdates []time.Time
+		name := z.id
+		for _, p := range obs.props {
+			var err error
+			switch p.Name {
+			case "DTSTART":
+				start, err = time.Parse("20060102T150405", p.Value)
+			case "TZOFFSETFROM":
+				from, err = parseOffset(p.Value)
+			case "TZOFFSETTO":
+				to, err = parseOffset(p.Value)
+			case "TZNAME":
+				name = p.Value
+			case "RRULE":
+				rule, err = parseRRule(p.Value)
+			case "RDATE":
+				for _, v := range strings.Split(p.Value, ",") {
+					var t time.Time
+					t, err = time.Parse("20060102T150405", v)
+					rdates = append(rdates, t)
+				}
+			}
+			if err != nil {
+				return nil, fmt.Errorf("VTIMEZONE %s: %s: %w", z.id, p.Name, err)
+			}
+		}
+		if start.IsZero() {
+			return nil, fmt.Errorf("VTIMEZONE %s: observance without DTSTART", z.id)
+		}
+		if first.IsZero() || start.Before(first) {
+			first, z.initial = start, from
+		}
+		onsets := append([]time.Time{start}, rdates...)
+		if rule != nil {
+			onsets = onsets[:0]
+			until, _ := time.Parse("20060102T150405Z", rule.until)
+			rule.each(start, func(t time.Time) bool {
+				if !until.IsZero() && t.Add(-time.Duration(from)*time.Second).After(until) {
+					return false
+				}
+				onsets = append(onsets, t)
+				return true
+			})
+			onsets = append(onsets, rdates...)
+		}
+		for _, t := range onsets {
+			z.transitions = append(z.transitions, transition{wall: t, offset: to, name: name})
+		}
+	}
+	sort.Slice(z.transitions, func(i, j int) bool { return z.transitions[i].wall.Before(z.transitions[j].wall) })
+	return z, nil
+}
+
+// parseOffset reads a UTC offset such as -0500 or +053000 into seconds.
+func parseOffset(s string) (int, error) {
+	if len(s) != 5 && len(s) != 7 || s[0] != '+' && s[0] != '-' {
+		return 0, fmt.Errorf("invalid offset %q", s)
+	}
+	n, err := strconv.Atoi(s[1:])
+	if err != nil {
+		return 0, fmt.Errorf("invalid offset %q", s)
+	}
+	if len(s) == 5 {
+		n *= 100
+	}
+	seconds := n/10000*3600 + n/100%100*60 + n%100
+	if s[0] == '-' {
+		seconds = -seconds
+	}
+	return seconds, nil
+}
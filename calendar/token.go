@@ -0,0 +1,94 @@
+package calendar
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"obsidian-ai-planner/secrets"
+
+	"golang.org/x/oauth2"
+)
+
+// GoogleTokenSecret names the Google token in a secret store.
+const GoogleTokenSecret = "google-token"
+
+// TokenStore keeps the Google token between runs.
+type TokenStore interface {
+	// Load returns the saved token, or ErrNotAuthorized when there is none.
+	Load() (*oauth2.Token, error)
+	Save(tok *oauth2.Token) error
+}
+
+// SecretTokenStore keeps the token as JSON under Name in a secret store.
+type SecretTokenStore struct {
+	Secrets secrets.Store
+	Name    string
+}
+
+// NewSecretTokenStore keeps the Google token in s.
+func NewSecretTokenStore(s secrets.Store) SecretTokenStore {
+	return SecretTokenStore{Secrets: s, Name: GoogleTokenSecret}
+}
+
+func (s SecretTokenStore) Load() (*oauth2.Token, error) {
+	value, err := s.Secrets.Get(s.Name)
+	if errors.Is(err, secrets.ErrNotFound) {
+		return nil, ErrNotAuthorized
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loading the Google token: %w", err)
+	}
+	tok := &oauth2.Token{}
+	if err := json.Unmarshal([]byte(value), tok); err != nil {
+		return nil, fmt.Errorf("loading the Google token: %w", err)
+	}
+	return tok, nil
+}
+
+func (s SecretTokenStore) Save(tok *oauth2.Token) error {
+	data, err := json.Marshal(tok)
+	if err != nil {
+		return err
+	}
+	if err := s.Secrets.Set(s.Name, string(data)); err != nil {
+		return fmt.Errorf("saving the Google token: %w", err)
+	}
+	return nil
+}
+
+// persistingTokenSource saves every new token its source hands out, so a
+// refresh is not lost when the program exits. A token that cannot be saved
+// is still handed out; the failure is kept for Err.
+type persistingTokenSource struct {
+	source oauth2.TokenSource
+	store  TokenStore
+
+	mu    sync.Mutex
+	saved string // the access token last saved
+	err   error  // why the last save failed
+}
+
+func (s *persistingTokenSource) Token() (*oauth2.Token, error) {
+	tok, err := s.source.Token()
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if tok.AccessToken != s.saved {
+		s.err = s.store.Save(tok)
+		if s.err == nil {
+			s.saved = tok.AccessToken
+		}
+	}
+	return tok, nil
+}
+
+// Err returns why the last refreshed token could not be saved, or nil.
+func (s *persistingTokenSource) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
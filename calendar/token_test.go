@@ -0,0 +1,105 @@
+package calendar
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"obsidian-ai-planner/secrets"
+
+	"golang.org/x/oauth2"
+)
+
+type memorySecrets map[string]string
+
+func (m memorySecrets) Get(name string) (string, error) {
+	v, ok := m[name]
+	if !ok {
+		return "", secrets.ErrNotFound
+	}
+	return v, nil
+}
+func (m memorySecrets) Set(name, value string) error { m[name] = value; return nil }
+func (m memorySecrets) Delete(name string) error     { delete(m, name); return nil }
+
+// refreshingSource hands out the next token on each call, as if each had
+// expired.
+type refreshingSource struct{ tokens []string }
+
+func (s *refreshingSource) Token() (*oauth2.Token, error) {
+	tok := &oauth2.Token{AccessToken: s.tokens[0], RefreshToken: "refresh"}
+	if len(s.tokens) > 1 {
+		s.tokens = s.tokens[1:]
+	}
+	return tok, nil
+}
+
+func TestPersistingTokenSource(t *testing.T) {
+	store := NewSecretTokenStore(memorySecrets{})
+	if _, err := store.Load(); !errors.Is(err, ErrNotAuthorized) {
+		t.Errorf("Expected ErrNotAuthorized before any token, got %v", err)
+	}
+	if err := store.Save(&oauth2.Token{AccessToken: "first", RefreshToken: "refresh"}); err != nil {
+		t.Fatal(err)
+	}
+
+	source := &persistingTokenSource{
+		source: &refreshingSource{tokens: []string{"first", "second"}},
+		store:  store,
+		saved:  "first",
+	}
+	for range 3 {
+		if _, err := source.Token(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tok, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.AccessToken != "second" || tok.RefreshToken != "refresh" {
+		t.Errorf("Expected the refreshed token to be saved, got %+v", tok)
+	}
+}
+
+func TestImportLegacyToken(t *testing.T) {
+	t.Chdir(t.TempDir())
+	store := NewSecretTokenStore(memorySecrets{})
+	if _, err := importLegacyToken(store); !errors.Is(err, ErrNotAuthorized) {
+		t.Errorf("Expected ErrNotAuthorized without token.json, got %v", err)
+	}
+
+	if err := os.WriteFile(legacyTokenFile, []byte(`{"access_token":"old","refresh_token":"refresh"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tok, err := importLegacyToken(store)
+	if err != nil || tok.AccessToken != "old" {
+		t.Fatalf("Expected the legacy token, got %+v, %v", tok, err)
+	}
+	if saved, _ := store.Load(); saved == nil || saved.RefreshToken != "refresh" {
+		t.Errorf("Expected the legacy token in the store, got %+v", saved)
+	}
+	if _, err := os.Stat(legacyTokenFile); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected token.json to be removed, got %v", err)
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Load() (*oauth2.Token, error) { return nil, ErrNotAuthorized }
+func (failingStore) Save(*oauth2.Token) error     { return errors.New("keyring locked") }
+
+func TestPersistingTokenSource_SaveFails(t *testing.T) {
+	source := &persistingTokenSource{
+		source: &refreshingSource{tokens: []string{"second"}},
+		store:  failingStore{},
+		saved:  "first",
+	}
+	tok, err := source.Token()
+	if err != nil || tok.AccessToken != "second" {
+		t.Fatalf("Expected the refreshed token despite the failed save, got %+v, %v", tok, err)
+	}
+	if source.Err() == nil {
+		t.Error("Expected the failed save to be reported by Err")
+	}
+}
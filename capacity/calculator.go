@@ -0,0 +1,116 @@
+package capacity
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"obsidian-ai-planner/calendar"
+)
+
+// Settings are the working hours and overheads a day's capacity is computed
+// with.
+type Settings struct {
+	// WorkStart and WorkEnd are offsets from midnight.
+	WorkStart time.Duration
+	WorkEnd   time.Duration
+	// MeetingOverhead is the preparation and follow-up a meeting costs, as
+	// a fraction of its length.
+	MeetingOverhead float64
+	// SwitchMinutes is taken from the gap after each meeting for getting
+	// back into work.
+	SwitchMinutes int
+}
+
+// Gap is a free interval of the working day. Minutes is what is left of it
+// once the switch after the preceding meeting is paid.
+type Gap struct {
+	calendar.Gap
+	Minutes int `json:"minutes"`
+}
+
+// Capacity is the arithmetic of a working day, computed so the model does
+// not have to.
+type Capacity struct {
+	WorkMinutes     int   `json:"workMinutes"`
+	MeetingMinutes  int   `json:"meetingMinutes"`
+	OverheadMinutes int   `json:"overheadMinutes"`
+	FreeMinutes     int   `json:"freeMinutes"`
+	FocusBlocks     int   `json:"focusBlocks"`
+	FocusMinutes    int   `json:"focusMinutes"`
+	Gaps            []Gap `json:"gaps"`
+	// Fragmentation is the share of the time between meetings that falls
+	// in gaps too short to be focus blocks, from 0 to 1.
+	Fragmentation float64 `json:"fragmentation"`
+}
+
+// Calculate works out the capacity of day from its calendar events, using
+// the free gaps calendar.FreeGaps finds. Gaps with at least
+// calendar.DeepWorkMinimum left after the switch are the focus blocks.
+func Calculate(day time.Time, events []calendar.Event, s Settings) Capacity {
+	free := calendar.FreeGaps(day, events, s.WorkStart, s.WorkEnd)
+	midnight := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	workStart := midnight.Add(s.WorkStart)
+
+	c := Capacity{WorkMinutes: minutes(s.WorkEnd - s.WorkStart)}
+	c.MeetingMinutes = c.WorkMinutes
+	gapMinutes, shortMinutes := 0, 0
+	for _, g := range free {
+		raw := minutes(g.Duration())
+		c.MeetingMinutes -= raw
+		gap := Gap{Gap: g, Minutes: raw}
+		if g.Start.After(workStart) {
+			// A gap that does not open the day follows a meeting.
+			gap.Minutes = max(0, raw-s.SwitchMinutes)
+		}
+		c.OverheadMinutes += raw - gap.Minutes
+		// A gap is a focus block only if 2h are left once the switch is paid.
+		gap.Kind = calendar.Admin
+		if gap.Minutes >= minutes(calendar.DeepWorkMinimum) {
+			gap.Kind = calendar.DeepWork
+		}
+		c.Gaps = append(c.Gaps, gap)
+
+		gapMinutes += gap.Minutes
+		if gap.Kind == calendar.DeepWork {
+			c.FocusBlocks++
+			c.FocusMinutes += gap.Minutes
+		} else {
+			shortMinutes += gap.Minutes
+		}
+	}
+	if gapMinutes > 0 {
+		c.Fragmentation = float64(shortMinutes) / float64(gapMinutes)
+	}
+
+	prep := int(math.Round(float64(c.MeetingMinutes) * s.MeetingOverhead))
+	c.OverheadMinutes += prep
+	c.FreeMinutes = max(0, gapMinutes-prep)
+	return c
+}
+
+// FreeGaps returns the gaps as calendar gaps, classified by what is left of
+// them after the switch.
+func (c *Capacity) FreeGaps() []calendar.Gap {
+	gaps := make([]calendar.Gap, len(c.Gaps))
+	for i, g := range c.Gaps {
+		gaps[i] = g.Gap
+	}
+	return gaps
+}
+
+// String states the figures as facts for the prompt.
+func (c *Capacity) String() string {
+	if c == nil {
+		return "unknown, no calendar is connected"
+	}
+	return fmt.Sprintf("%d working minutes, %d in meetings, %d lost to meeting overhead and context switches, "+
+		"%d free for planned work; %d focus block(s) of 2h or more totalling %d minutes; "+
+		"%.0f%% of the time between meetings is fragmented into gaps under 2h",
+		c.WorkMinutes, c.MeetingMinutes, c.OverheadMinutes, c.FreeMinutes,
+		c.FocusBlocks, c.FocusMinutes, c.Fragmentation*100)
+}
+
+func minutes(d time.Duration) int {
+	return int(d / time.Minute)
+}
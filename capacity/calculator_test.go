@@ -0,0 +1,137 @@
+package capacity
+
+import (
+	"testing"
+	"time"
+
+	"obsidian-ai-planner/calendar"
+)
+
+var testSettings = Settings{
+	WorkStart:       9 * time.Hour,
+	WorkEnd:         17 * time.Hour,
+	MeetingOverhead: 0.5,
+	SwitchMinutes:   10,
+}
+
+func event(name, start, end string) calendar.Event {
+	s, _ := time.Parse(time.RFC3339, start)
+	e, _ := time.Parse(time.RFC3339, end)
+	return calendar.Event{Name: name, Start: s, End: e, Type: "event"}
+}
+
+func TestCalculate(t *testing.T) {
+	day := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	events := []calendar.Event{
+		event("Standup", "2024-01-10T09:30:00Z", "2024-01-10T10:00:00Z"),
+		// Overlaps the standup, so the two count as one meeting.
+		event("Sync", "2024-01-10T09:45:00Z", "2024-01-10T10:30:00Z"),
+		event("Review", "2024-01-10T15:00:00Z", "2024-01-10T16:00:00Z"),
+		{Name: "Holiday", Start: day, End: day.AddDate(0, 0, 1), AllDay: true, Type: "event"},
+		{Name: "Focus", Start: day.Add(11 * time.Hour), End: day.Add(13 * time.Hour), Type: "focusTime"},
+	}
+
+	c := Calculate(day, events, testSettings)
+	if c.WorkMinutes != 480 {
+		t.Errorf("Expected 480 working minutes, got %d", c.WorkMinutes)
+	}
+	if c.MeetingMinutes != 120 {
+		t.Errorf("Expected 120 meeting minutes, got %d", c.MeetingMinutes)
+	}
+	// Gaps: 09:00-09:30 (30), 10:30-15:00 (270-10), 16:00-17:00 (60-10).
+	if len(c.Gaps) != 3 {
+		t.Fatalf("Expected 3 gaps, got %d", len(c.Gaps))
+	}
+	if c.Gaps[1].Minutes != 260 {
+		t.Errorf("Expected 260 minutes in the second gap, got %d", c.Gaps[1].Minutes)
+	}
+	if c.FocusBlocks != 1 || c.FocusMinutes != 260 {
+		t.Errorf("Expected 1 focus block of 260 minutes, got %d of %d", c.FocusBlocks, c.FocusMinutes)
+	}
+	// 20 minutes of switches plus half of the 120 meeting minutes.
+	if c.OverheadMinutes != 80 {
+		t.Errorf("Expected 80 overhead minutes, got %d", c.OverheadMinutes)
+	}
+	if c.FreeMinutes != 280 {
+		t.Errorf("Expected 280 free minutes, got %d", c.FreeMinutes)
+	}
+	if c.Fragmentation != 80.0/340 {
+		t.Errorf("Expected fragmentation %v, got %v", 80.0/340, c.Fragmentation)
+	}
+}
+
+func TestCalculate_ClipsToWorkingHours(t *testing.T) {
+	day := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	events := []calendar.Event{
+		event("Early", "2024-01-10T08:00:00Z", "2024-01-10T10:00:00Z"),
+		event("Late", "2024-01-10T16:30:00Z", "2024-01-10T18:00:00Z"),
+	}
+
+	c := Calculate(day, events, Settings{WorkStart: 9 * time.Hour, WorkEnd: 17 * time.Hour})
+	if c.MeetingMinutes != 90 {
+		t.Errorf("Expected 90 meeting minutes, got %d", c.MeetingMinutes)
+	}
+	if c.FreeMinutes != 390 || c.FocusBlocks != 1 || c.Fragmentation != 0 {
+		t.Errorf("Expected one 390 minute focus block, got %+v", c)
+	}
+}
+
+func TestCalculate_EmptyDay(t *testing.T) {
+	c := Calculate(time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC), nil, testSettings)
+	if c.FreeMinutes != 480 || c.FocusBlocks != 1 || c.OverheadMinutes != 0 {
+		t.Errorf("Expected the whole day free, got %+v", c)
+	}
+}
+
+func TestCalculate_BackToBack(t *testing.T) {
+	day := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	var events []calendar.Event
+	for h := 9; h < 17; h += 2 {
+		start := day.Add(time.Duration(h) * time.Hour)
+		events = append(events, event("Meeting", start.Format(time.RFC3339), start.Add(90*time.Minute).Format(time.RFC3339)))
+	}
+
+	c := Calculate(day, events, testSettings)
+	if c.FocusBlocks != 0 {
+		t.Errorf("Expected no focus blocks, got %d", c.FocusBlocks)
+	}
+	if c.Fragmentation != 1 {
+		t.Errorf("Expected fully fragmented time, got %v", c.Fragmentation)
+	}
+	// 4 gaps of 30 minutes less 10 each, and 180 minutes of overhead.
+	if c.FreeMinutes != 0 {
+		t.Errorf("Expected no free minutes, got %d", c.FreeMinutes)
+	}
+}
+
+func TestCalculate_FocusBlockBoundary(t *testing.T) {
+	day := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	// 10:00-12:10 leaves exactly 2h after the switch, 13:00-15:09 falls
+	// a minute short of it.
+	events := []calendar.Event{
+		event("Standup", "2024-01-10T09:00:00Z", "2024-01-10T10:00:00Z"),
+		event("Lunch talk", "2024-01-10T12:10:00Z", "2024-01-10T13:00:00Z"),
+		event("Review", "2024-01-10T15:09:00Z", "2024-01-10T17:00:00Z"),
+	}
+
+	c := Calculate(day, events, testSettings)
+	if len(c.Gaps) != 2 {
+		t.Fatalf("Expected 2 gaps, got %+v", c.Gaps)
+	}
+	if c.Gaps[0].Minutes != 120 || c.Gaps[0].Kind != calendar.DeepWork {
+		t.Errorf("Expected a 120 minute focus block, got %+v", c.Gaps[0])
+	}
+	if c.Gaps[1].Minutes != 119 || c.Gaps[1].Kind != calendar.Admin {
+		t.Errorf("Expected a 119 minute admin gap, got %+v", c.Gaps[1])
+	}
+	if c.FocusBlocks != 1 || c.FocusMinutes != 120 {
+		t.Errorf("Expected 1 focus block of 120 minutes, got %d of %d", c.FocusBlocks, c.FocusMinutes)
+	}
+}
+
+func TestCapacity_StringNil(t *testing.T) {
+	var c *Capacity
+	if got := c.String(); got != "unknown, no calendar is connected" {
+		t.Errorf("Expected the no calendar message, got %q", got)
+	}
+}
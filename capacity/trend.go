@@ -0,0 +1,156 @@
+// Package capacity turns the daily notes and calendar into the figures the
+// planner needs to judge how much a day can hold.
+package capacity
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"obsidian-ai-planner/vault"
+)
+
+// TrendWindow is the number of daily notes the rolling average covers.
+const TrendWindow = 5
+
+// TrendLookback is how many days of notes are read to compute the trend.
+const TrendLookback = 14
+
+// UnplannedLevel is the qualitative share of a day's work that was not
+// planned, using the wording of the spec.
+type UnplannedLevel int
+
+const (
+	NoUnplanned UnplannedLevel = iota
+	ALittleUnplanned
+	SomeUnplanned
+	MostlyUnplanned
+	AllUnplanned
+)
+
+func (l UnplannedLevel) String() string {
+	switch l {
+	case NoUnplanned:
+		return "nothing unplanned"
+	case ALittleUnplanned:
+		return "a little"
+	case SomeUnplanned:
+		return "some"
+	case MostlyUnplanned:
+		return "mostly unplanned"
+	case AllUnplanned:
+		return "everything was unplanned"
+	}
+	return "unknown"
+}
+
+// LevelFor buckets an unplanned ratio between 0 and 1.
+func LevelFor(ratio float64) UnplannedLevel {
+	switch {
+	case ratio <= 0:
+		return NoUnplanned
+	case ratio <= 0.25:
+		return ALittleUnplanned
+	case ratio <= 0.5:
+		return SomeUnplanned
+	case ratio < 1:
+		return MostlyUnplanned
+	}
+	return AllUnplanned
+}
+
+// DayTrend is one daily note's share of unplanned work. Rolling averages the
+// ratio over the TrendWindow notes ending on this day.
+type DayTrend struct {
+	Date      time.Time      `json:"date"`
+	Unplanned int            `json:"unplanned"`
+	Total     int            `json:"total"`
+	Ratio     float64        `json:"ratio"`
+	Rolling   float64        `json:"rolling"`
+	Level     UnplannedLevel `json:"level"`
+}
+
+// Trend is the rolling unplanned-work average over recent daily notes.
+type Trend struct {
+	Days      []DayTrend     `json:"days"`
+	Current   float64        `json:"current"`
+	Level     UnplannedLevel `json:"level"`
+	Direction string         `json:"direction"`
+}
+
+// AnalyzeTrend computes per-day unplanned ratios from the day statistics,
+// skipping days without tasks, and the rolling average across them.
+func AnalyzeTrend(days []vault.DayStats) Trend {
+	var t Trend
+	var ratios []float64
+	for _, d := range days {
+		if d.Total == 0 {
+			continue
+		}
+		ratio := float64(d.Unplanned.Total) / float64(d.Total)
+		ratios = append(ratios, ratio)
+		window := ratios[max(0, len(ratios)-TrendWindow):]
+		sum := 0.0
+		for _, r := range window {
+			sum += r
+		}
+		t.Days = append(t.Days, DayTrend{
+			Date:      d.Date,
+			Unplanned: d.Unplanned.Total,
+			Total:     d.Total,
+			Ratio:     ratio,
+			Rolling:   sum / float64(len(window)),
+			Level:     LevelFor(ratio),
+		})
+	}
+	if len(t.Days) == 0 {
+		t.Direction = "no data"
+		return t
+	}
+
+	t.Current = t.Days[len(t.Days)-1].Rolling
+	t.Level = LevelFor(t.Current)
+	first := t.Days[max(0, len(t.Days)-TrendWindow)].Rolling
+	switch {
+	case t.Current-first > 0.1:
+		t.Direction = "rising"
+	case first-t.Current > 0.1:
+		t.Direction = "falling"
+	default:
+		t.Direction = "steady"
+	}
+	return t
+}
+
+// String is the one-line summary given to the model.
+func (t Trend) String() string {
+	if len(t.Days) == 0 {
+		return "no recent daily notes"
+	}
+	return fmt.Sprintf("%s (rolling %.0f%% of tasks were Bonus Items over the last %d notes, %s)",
+		t.Level, t.Current*100, min(len(t.Days), TrendWindow), t.Direction)
+}
+
+// Markdown is the narrative written to the weekly note. Like the spec asks,
+// it describes the trend without numbers.
+func (t Trend) Markdown(updated time.Time) string {
+	if len(t.Days) == 0 {
+		return "No daily notes with tasks yet."
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Unplanned work lately: **%s**, and %s.\n\n", t.Level, t.Direction)
+	for _, d := range t.Days {
+		fmt.Fprintf(&b, "- %s: %s\n", d.Date.Format("Mon Jan 2"), d.Level)
+	}
+	fmt.Fprintf(&b, "\n_Updated by the planner on %s._", updated.Format("2006-01-02"))
+	return b.String()
+}
+
+// RecentTrend reads the daily notes of the TrendLookback days up to date.
+func RecentTrend(v *vault.Vault, date time.Time) (Trend, error) {
+	stats, err := v.Stats(date.AddDate(0, 0, -TrendLookback+1), date)
+	if err != nil {
+		return Trend{}, err
+	}
+	return AnalyzeTrend(stats.Days), nil
+}
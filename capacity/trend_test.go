@@ -0,0 +1,93 @@
+package capacity
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"obsidian-ai-planner/vault"
+)
+
+func TestLevelFor(t *testing.T) {
+	tests := []struct {
+		ratio float64
+		want  UnplannedLevel
+	}{
+		{0, NoUnplanned},
+		{0.2, ALittleUnplanned},
+		{0.25, ALittleUnplanned},
+		{0.4, SomeUnplanned},
+		{0.75, MostlyUnplanned},
+		{1, AllUnplanned},
+	}
+	for _, tt := range tests {
+		if got := LevelFor(tt.ratio); got != tt.want {
+			t.Errorf("LevelFor(%v): expected %v, got %v", tt.ratio, tt.want, got)
+		}
+	}
+}
+
+func day(d, unplanned, total int) vault.DayStats {
+	return vault.DayStats{
+		Date:      time.Date(2024, 1, d, 0, 0, 0, 0, time.Local),
+		Unplanned: vault.SectionStats{Total: unplanned},
+		Total:     total,
+	}
+}
+
+func TestAnalyzeTrend(t *testing.T) {
+	trend := AnalyzeTrend([]vault.DayStats{
+		day(1, 0, 4),
+		day(2, 0, 0),
+		day(3, 1, 4),
+		day(4, 2, 4),
+		day(5, 3, 4),
+		day(6, 4, 4),
+		day(7, 4, 4),
+	})
+
+	if len(trend.Days) != 6 {
+		t.Fatalf("Expected 6 days with tasks, got %d", len(trend.Days))
+	}
+	if trend.Days[1].Rolling != 0.125 {
+		t.Errorf("Expected rolling 0.125 on the second day, got %v", trend.Days[1].Rolling)
+	}
+	// The last five ratios are .25, .5, .75, 1 and 1.
+	if trend.Current != 0.7 {
+		t.Errorf("Expected current 0.7, got %v", trend.Current)
+	}
+	if trend.Level != MostlyUnplanned {
+		t.Errorf("Expected %v, got %v", MostlyUnplanned, trend.Level)
+	}
+	if trend.Direction != "rising" {
+		t.Errorf("Expected rising, got %s", trend.Direction)
+	}
+}
+
+func TestAnalyzeTrend_Empty(t *testing.T) {
+	trend := AnalyzeTrend(nil)
+	if trend.Direction != "no data" {
+		t.Errorf("Expected no data, got %s", trend.Direction)
+	}
+	if got := trend.String(); got != "no recent daily notes" {
+		t.Errorf("Expected no recent daily notes, got %q", got)
+	}
+}
+
+func TestTrend_Markdown(t *testing.T) {
+	trend := AnalyzeTrend([]vault.DayStats{day(1, 1, 4), day(2, 1, 4)})
+	md := trend.Markdown(time.Date(2024, 1, 3, 9, 0, 0, 0, time.Local))
+
+	if !strings.Contains(md, "**a little**, and steady") {
+		t.Errorf("Expected the level and direction, got %q", md)
+	}
+	if !strings.Contains(md, "- Tue Jan 2: a little") {
+		t.Errorf("Expected a line per day, got %q", md)
+	}
+	if strings.ContainsAny(md, "%") {
+		t.Errorf("Expected no percentages, got %q", md)
+	}
+	if !strings.HasSuffix(md, "_Updated by the planner on 2024-01-03._") {
+		t.Errorf("Expected the update line, got %q", md)
+	}
+}
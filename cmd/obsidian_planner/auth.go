@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"obsidian-ai-planner/calendar"
+	"obsidian-ai-planner/local_ai"
+)
+
+const authUsage = "usage: obsidian_planner auth google [--flow loopback|paste|device] [--port N]"
+
+// runAuth signs in to a calendar service and saves the token, so the chat
+// never has to stop for a browser.
+func runAuth(args []string) error {
+	if len(args) == 0 || args[0] != "google" {
+		return errors.New(authUsage)
+	}
+	flags := flag.NewFlagSet("auth google", flag.ContinueOnError)
+	mode := flags.String("flow", "loopback", "how to sign in: loopback (browser on this machine), paste (browser elsewhere) or device (code entry)")
+	port := flags.Int("port", 0, "port for the loopback redirect; 0 picks a free one")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	var flow calendar.AuthFlow
+	switch *mode {
+	case "loopback":
+		flow = calendar.LoopbackFlow{Port: *port, Out: os.Stdout}
+	case "paste":
+		flow = calendar.PasteFlow{In: os.Stdin, Out: os.Stdout}
+	case "device":
+		flow = calendar.DeviceFlow{Out: os.Stdout}
+	default:
+		return errors.New(authUsage)
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	auth, err := local_ai.GoogleAuth(cfg)
+	if err != nil {
+		return err
+	}
+	if err := auth.Authorize(context.Background(), flow); err != nil {
+		return err
+	}
+	fmt.Println("Google Calendar authorized.")
+	return nil
+}
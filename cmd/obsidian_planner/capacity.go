@@ -0,0 +1,29 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	"obsidian-ai-planner/capacity"
+	"obsidian-ai-planner/vault"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// updateCapacity refreshes the capacity trend in this week's note. The trend
+// covers the days before today, so the block only changes once a day and
+// later chats leave the note, and the undo journal, alone.
+func (m *chatModel) updateCapacity() tea.Cmd {
+	return func() tea.Msg {
+		now := time.Now()
+		trend, err := capacity.RecentTrend(m.modelInfo.Vault, now.AddDate(0, 0, -1))
+		if err != nil {
+			return errMsg(err)
+		}
+		err = m.modelInfo.Vault.UpdateCapacityTrend(now, trend.Markdown(now))
+		if err != nil && !errors.Is(err, vault.ErrNoteNotFound) {
+			return errMsg(err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"obsidian-ai-planner/vault"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	carryStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+	dropStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("208"))
+	deferStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("33"))
+)
+
+// carryoverModel lets the user decide, task by task, what happens to the
+// incomplete work from the previous business day.
+type carryoverModel struct {
+	from    string
+	today   string
+	tasks   []*vault.Task
+	actions []vault.CarryoverAction
+	until   []time.Time
+	deferTo time.Time
+	cursor  int
+}
+
+type carryoverMsg struct {
+	from    string
+	today   string
+	tasks   []*vault.Task
+	deferTo time.Time
+}
+
+type carryoverAppliedMsg string
+
+func newCarryoverModel(msg carryoverMsg) *carryoverModel {
+	return &carryoverModel{
+		from:    msg.from,
+		today:   msg.today,
+		tasks:   msg.tasks,
+		actions: make([]vault.CarryoverAction, len(msg.tasks)),
+		until:   make([]time.Time, len(msg.tasks)),
+		deferTo: msg.deferTo,
+	}
+}
+
+// Update handles a key press and reports whether the picker is finished and,
+// if so, whether the user confirmed their choices.
+func (c *carryoverModel) Update(msg tea.KeyMsg) (done bool, confirmed bool) {
+	switch msg.String() {
+	case "up", "k":
+		if c.cursor > 0 {
+			c.cursor--
+		}
+	case "down", "j":
+		if c.cursor < len(c.tasks)-1 {
+			c.cursor++
+		}
+	case "c":
+		c.actions[c.cursor] = vault.CarryForward
+	case "d":
+		c.actions[c.cursor] = vault.Drop
+	case "f":
+		c.actions[c.cursor] = vault.Defer
+	case " ", "tab":
+		c.actions[c.cursor] = (c.actions[c.cursor] + 1) % 3
+	case "left", "h":
+		c.moveDeferral(-1)
+	case "right", "l":
+		c.moveDeferral(1)
+	case "enter":
+		return true, true
+	case "esc":
+		return true, false
+	}
+	if c.actions[c.cursor] == vault.Defer && c.until[c.cursor].IsZero() {
+		c.until[c.cursor] = c.deferTo
+	}
+	return false, false
+}
+
+// moveDeferral shifts the date the task under the cursor is deferred to,
+// never earlier than the first day it could be deferred to.
+func (c *carryoverModel) moveDeferral(days int) {
+	if c.actions[c.cursor] != vault.Defer || c.until[c.cursor].IsZero() {
+		return
+	}
+	until := c.until[c.cursor].AddDate(0, 0, days)
+	if until.Before(c.deferTo) {
+		return
+	}
+	c.until[c.cursor] = until
+}
+
+func (c *carryoverModel) Decisions() []vault.CarryoverDecision {
+	decisions := make([]vault.CarryoverDecision, len(c.tasks))
+	for i, t := range c.tasks {
+		decisions[i] = vault.CarryoverDecision{Task: t, Action: c.actions[i], Until: c.until[i]}
+	}
+	return decisions
+}
+
+func (c *carryoverModel) View() string {
+	var b strings.Builder
+	b.WriteString(focusedStyle.Render("Unfinished from your last note:"))
+	b.WriteString("\n\n")
+	for i, t := range c.tasks {
+		cursor := "  "
+		if i == c.cursor {
+			cursor = focusedStyle.Render("> ")
+		}
+		var action string
+		switch c.actions[i] {
+		case vault.CarryForward:
+			action = carryStyle.Render("[carry]")
+		case vault.Drop:
+			action = dropStyle.Render("[drop] ")
+		case vault.Defer:
+			action = deferStyle.Render("[defer " + c.until[i].Format("Mon Jan 2") + "]")
+		}
+		fmt.Fprintf(&b, "%s%s %s (%s)\n", cursor, action, t.Text, t.Section)
+	}
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("c carry • d drop • f defer • ←/→ defer date • space cycle • enter apply • esc decide later"))
+	return b.String()
+}
+
+// summary describes what applying the decisions did.
+func (c *carryoverModel) summary() string {
+	counts := make(map[vault.CarryoverAction]int)
+	for _, a := range c.actions {
+		counts[a]++
+	}
+	return fmt.Sprintf("Carried %d, dropped %d and deferred %d task(s) from %s.",
+		counts[vault.CarryForward], counts[vault.Drop], counts[vault.Defer], c.from)
+}
@@ -2,10 +2,13 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	_ "log"
 	"obsidian-ai-planner/local_ai"
+	"obsidian-ai-planner/vault"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textarea"
@@ -31,24 +34,24 @@ type chatModel struct {
 	spinner     spinner.Model
 	loading     bool
 	modelInfo   *local_ai.ModelInfo
+	carryover   *carryoverModel
+	review      *reviewModel
+	watcher     *vault.Watcher
 }
 
-func initialChatModel(initialMsg string) chatModel {
+func initialChatModel(initialMsg string) (chatModel, error) {
 	ctx := context.Background()
 	s := spinner.New()
 	s.Spinner = spinner.Dot
 	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
 
-	modelInfo, err := local_ai.NewOllamaModel(ctx)
+	cfg, err := loadConfig()
 	if err != nil {
-		// Since initialChatModel is not designed to return an error,
-		// we can't easily propagate it here.
-		// However, NewOllamaModel currently only returns an error if
-		// something fundamentally fails in GenKit/Ollama setup which
-		// is unlikely given the current implementation (it always returns nil error).
-		// If it did return an error, we'd probably want to handle it in the model.
-		// For now, we'll just panic if it's a genuine failure.
-		panic(err)
+		return chatModel{}, err
+	}
+	modelInfo, err := local_ai.NewOllamaModel(ctx, cfg)
+	if err != nil {
+		return chatModel{}, err
 	}
 	local_ai.DefinePlannerFlow(modelInfo)
 
@@ -86,7 +89,7 @@ Type a message and press Enter to send.`
 		spinner:     s,
 		loading:     false,
 		modelInfo:   modelInfo,
-	}
+	}, nil
 }
 
 type cmdArgMsg string
@@ -100,8 +103,93 @@ func cmdWithStr(s string) tea.Cmd {
 	}
 }
 
+type dailyNoteMsg struct {
+	path    string
+	created bool
+}
+
+// ensureDailyNote creates today's note from the vault template so the
+// planner has somewhere to write before Obsidian has been opened.
+func (m *chatModel) ensureDailyNote() tea.Cmd {
+	return func() tea.Msg {
+		if m.modelInfo.Vault == nil {
+			return nil
+		}
+		path, created, err := m.modelInfo.Vault.EnsureDailyNote(time.Now())
+		if err != nil {
+			return errMsg(err)
+		}
+		return dailyNoteMsg{path: path, created: created}
+	}
+}
+
+// findCarryover looks for unfinished tasks in the previous business day's
+// note once today's note is known to exist.
+func (m *chatModel) findCarryover(today string) tea.Cmd {
+	return func() tea.Msg {
+		holidays := m.modelInfo.Config.HolidayDates()
+		from, tasks, err := m.modelInfo.Vault.FindCarryover(time.Now(), holidays)
+		if err != nil {
+			return errMsg(err)
+		}
+		if len(tasks) == 0 {
+			return nil
+		}
+		return carryoverMsg{from: from, today: today, tasks: tasks, deferTo: vault.NextBusinessDay(time.Now(), holidays)}
+	}
+}
+
+func (m *chatModel) applyCarryover(c *carryoverModel) tea.Cmd {
+	return func() tea.Msg {
+		if err := m.modelInfo.Vault.ApplyCarryover(c.from, c.today, c.Decisions()); err != nil {
+			return errMsg(err)
+		}
+		return carryoverAppliedMsg(c.summary())
+	}
+}
+
+type deferredMsg string
+
+// deferTask handles "/defer <when> <task>", queueing the first open task in
+// today's note that matches for the given day.
+func (m *chatModel) deferTask(args string) tea.Cmd {
+	return func() tea.Msg {
+		if m.modelInfo.Vault == nil {
+			return errMsg(fmt.Errorf("no vault configured, run configure first"))
+		}
+		when, query, _ := strings.Cut(strings.TrimSpace(args), " ")
+		if strings.EqualFold(when, "next") {
+			var rest string
+			rest, query, _ = strings.Cut(query, " ")
+			when += " " + rest
+		}
+		now := time.Now()
+		until, err := vault.ParseDeferDate(when, now)
+		if err != nil {
+			return errMsg(err)
+		}
+		path, err := m.modelInfo.Vault.DailyNotePath(now)
+		if err != nil {
+			return errMsg(err)
+		}
+		note, err := m.modelInfo.Vault.ReadDailyNote(path)
+		if err != nil {
+			return errMsg(err)
+		}
+		task := note.FindTask(query)
+		if task == nil {
+			return errMsg(fmt.Errorf("no open task matching %q in today's note", query))
+		}
+		text := task.Text
+		if err := m.modelInfo.Vault.Defer(path, task, until); err != nil {
+			return errMsg(err)
+		}
+		return deferredMsg(fmt.Sprintf("Deferred %q to %s.", text, until.Format("Monday, Jan 2")))
+	}
+}
+
 func (m chatModel) Init() tea.Cmd {
-	return tea.Batch(textarea.Blink, m.spinner.Tick, cmdWithStr(m.initialMsg))
+	return tea.Batch(textarea.Blink, m.spinner.Tick, m.ensureDailyNote(), cmdWithStr(m.initialMsg))
 }
 
 func (m *chatModel) runChatFlow(userPrompt string) tea.Cmd {
@@ -129,12 +217,42 @@ func (m *chatModel) runGenerateFlow(userPrompt string) tea.Cmd {
 			History:    m.history,
 		}
 
-		resp, err := m.modelInfo.GeneratePlan(ctx, input)
+		plan, err := m.modelInfo.GeneratePlan(ctx, input)
 		if err != nil {
 			return errMsg(err)
 		}
+		text := plan.Summary
+		if plan.Rationale != "" {
+			text += "\n\n" + plan.Rationale
+		}
+		if m.modelInfo.Vault == nil {
+			return cmdArgMsg(text)
+		}
 
-		return cmdArgMsg(resp)
+		now := time.Now()
+		path, _, err := m.modelInfo.Vault.EnsureDailyNote(now)
+		if err != nil {
+			return errMsg(err)
+		}
+		note, err := m.modelInfo.Vault.ReadDailyNote(path)
+		if err != nil {
+			return errMsg(err)
+		}
+		diffs, err := note.Diff(plan.Updates(now, note))
+		if err != nil {
+			return errMsg(fmt.Errorf("the plan cannot be applied to today's note: %w", err))
+		}
+		return planMsg{text: text, note: note, diffs: diffs}
+	}
+}
+
+func (m *chatModel) applyReview(r *reviewModel) tea.Cmd {
+	return func() tea.Msg {
+		// The note may have been edited in Obsidian while the diff was open.
+		if err := m.modelInfo.Vault.WriteDailyNote(r.note, r.Updates()); err != nil {
+			return errMsg(err)
+		}
+		return reviewAppliedMsg(r.summary())
 	}
 }
 
@@ -152,6 +270,33 @@ func (m *chatModel) runCondenseFlow() tea.Cmd {
 }
 
 func (m chatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	// The carryover picker takes the keyboard until it is dismissed.
+	if key, ok := msg.(tea.KeyMsg); ok && m.carryover != nil && key.Type != tea.KeyCtrlC {
+		done, confirmed := m.carryover.Update(key)
+		if !done {
+			return m, nil
+		}
+		c := m.carryover
+		m.carryover = nil
+		if !confirmed {
+			return m, nil
+		}
+		return m, m.applyCarryover(c)
+	}
+	// So does the plan review.
+	if key, ok := msg.(tea.KeyMsg); ok && m.review != nil && key.Type != tea.KeyCtrlC {
+		done, confirmed := m.review.Update(key)
+		if !done {
+			return m, nil
+		}
+		r := m.review
+		m.review = nil
+		if !confirmed {
+			return m, nil
+		}
+		return m, m.applyReview(r)
+	}
+
 	var (
 		tiCmd tea.Cmd
 		vpCmd tea.Cmd
@@ -164,6 +309,43 @@ func (m chatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	m.spinner, spCmd = m.spinner.Update(msg)
 
 	switch msg := msg.(type) {
+	case dailyNoteMsg:
+		if msg.created {
+			m.messages = append(m.messages, m.senderStyle.Render("Planner: ")+"Created today's note at "+msg.path)
+			m.viewport.SetContent(lipgloss.NewStyle().Width(m.viewport.Width).Render(strings.Join(m.messages, "\n")))
+			m.viewport.GotoBottom()
+		}
+		return m, tea.Batch(tiCmd, vpCmd, spCmd, m.findCarryover(msg.path), m.updateCapacity(), m.watchNotes(msg.path))
+	case watchStartedMsg:
+		m.watcher = msg.watcher
+		return m, tea.Batch(tiCmd, vpCmd, spCmd, waitForNoteChange(m.watcher))
+	case noteChangedMsg:
+		m.modelInfo.InvalidateContext()
+		m.messages = append(m.messages, noteChangedNotice(string(msg)))
+		m.viewport.SetContent(lipgloss.NewStyle().Width(m.viewport.Width).Render(strings.Join(m.messages, "\n")))
+		m.viewport.GotoBottom()
+		return m, tea.Batch(tiCmd, vpCmd, spCmd, waitForNoteChange(m.watcher))
+	case watchErrMsg:
+		// Keep watching; a single failed event should not stop refreshes.
+		m.messages = append(m.messages, m.senderStyle.Render("Error: ")+msg.err.Error())
+		m.viewport.SetContent(lipgloss.NewStyle().Width(m.viewport.Width).Render(strings.Join(m.messages, "\n")))
+		m.viewport.GotoBottom()
+		return m, tea.Batch(tiCmd, vpCmd, spCmd, waitForNoteChange(m.watcher))
+	case carryoverMsg:
+		m.carryover = newCarryoverModel(msg)
+	case planMsg:
+		m.loading = false
+		m.messages = append(m.messages, m.senderStyle.Render("Bot: ")+msg.text)
+		m.history = append(m.history, local_ai.Message{Role: "model", Content: msg.text})
+		m.viewport.SetContent(lipgloss.NewStyle().Width(m.viewport.Width).Render(strings.Join(m.messages, "\n")))
+		m.viewport.GotoBottom()
+		if len(msg.diffs) > 0 {
+			m.review = newReviewModel(msg)
+		}
+	case carryoverAppliedMsg, deferredMsg, reviewAppliedMsg, undoMsg, gapsMsg:
+		m.messages = append(m.messages, m.senderStyle.Render("Planner: ")+fmt.Sprint(msg))
+		m.viewport.SetContent(lipgloss.NewStyle().Width(m.viewport.Width).Render(strings.Join(m.messages, "\n")))
+		m.viewport.GotoBottom()
 	case condenseMsg:
 		m.loading = false
 		summary := string(msg)
@@ -191,6 +373,9 @@ func (m chatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.Type {
 		case tea.KeyCtrlC, tea.KeyEsc:
+			if m.watcher != nil {
+				m.watcher.Close()
+			}
 			fmt.Println(m.textarea.Value())
 			return m, tea.Quit
 		case tea.KeyEnter:
@@ -210,6 +395,27 @@ func (m chatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						m.runCondenseFlow(),
 					)
 				}
+				if command := strings.TrimSpace(userMsg); command == "/undo" || strings.HasPrefix(command, "/undo ") {
+					m.messages = append(m.messages, m.senderStyle.Render("You: ")+userMsg)
+					m.viewport.SetContent(lipgloss.NewStyle().Width(m.viewport.Width).Render(strings.Join(m.messages, "\n")))
+					m.textarea.Reset()
+					m.viewport.GotoBottom()
+					return m, tea.Batch(tiCmd, vpCmd, spCmd, m.undoCmd(strings.TrimPrefix(command, "/undo")))
+				}
+				if command := strings.TrimSpace(userMsg); command == "/gaps" || strings.HasPrefix(command, "/gaps ") {
+					m.messages = append(m.messages, m.senderStyle.Render("You: ")+userMsg)
+					m.viewport.SetContent(lipgloss.NewStyle().Width(m.viewport.Width).Render(strings.Join(m.messages, "\n")))
+					m.textarea.Reset()
+					m.viewport.GotoBottom()
+					return m, tea.Batch(tiCmd, vpCmd, spCmd, m.gapsCmd(strings.TrimPrefix(command, "/gaps")))
+				}
+				if args, ok := strings.CutPrefix(strings.TrimSpace(userMsg), "/defer "); ok {
+					m.messages = append(m.messages, m.senderStyle.Render("You: ")+userMsg)
+					m.viewport.SetContent(lipgloss.NewStyle().Width(m.viewport.Width).Render(strings.Join(m.messages, "\n")))
+					m.textarea.Reset()
+					m.viewport.GotoBottom()
+					return m, tea.Batch(tiCmd, vpCmd, spCmd, m.deferTask(args))
+				}
 				m.messages = append(m.messages, m.senderStyle.Render("You: ")+userMsg)
 				m.history = append(m.history, local_ai.Message{Role: "user", Content: userMsg})
 				m.viewport.SetContent(lipgloss.NewStyle().Width(m.viewport.Width).Render(strings.Join(m.messages, "\n")))
@@ -235,7 +441,12 @@ func (m chatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case errMsg:
 		m.err = msg
 		m.loading = false
-		m.messages = append(m.messages, m.senderStyle.Render("Error: ")+msg.Error())
+		label := "Error: "
+		var conflict *vault.ConflictError
+		if errors.As(msg, &conflict) {
+			label = "Conflict: "
+		}
+		m.messages = append(m.messages, m.senderStyle.Render(label)+msg.Error())
 		m.viewport.SetContent(lipgloss.NewStyle().Width(m.viewport.Width).Render(strings.Join(m.messages, "\n")))
 		m.viewport.GotoBottom()
 		return m, nil
@@ -246,7 +457,11 @@ func (m chatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (m chatModel) View() string {
 	var s string
-	if m.loading {
+	if m.carryover != nil {
+		s = m.carryover.View()
+	} else if m.review != nil {
+		s = m.review.View()
+	} else if m.loading {
 		s = m.spinner.View() + " Thinking..."
 	} else {
 		s = m.textarea.View()
@@ -26,6 +26,8 @@ var (
 )
 
 type configureModel struct {
+	// cfg is the saved config, its secret store already unlocked.
+	cfg        *configuration.Config
 	focusIndex int
 	inputs     []textinput.Model
 	cursorMode cursor.Mode
@@ -33,14 +35,22 @@ type configureModel struct {
 	err        error
 }
 
-func initialConfigureModel() configureModel {
+// initialConfigureModel loads the config and unlocks the secret store
+// before the form starts, as a passphrase prompt cannot share the terminal
+// with it.
+func initialConfigureModel() (configureModel, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return configureModel{}, err
+	}
+	if err := cfg.UnlockSecrets(); err != nil {
+		return configureModel{}, fmt.Errorf("opening the secret store: %w", err)
+	}
 	m := configureModel{
-		inputs: make([]textinput.Model, 3),
+		cfg:    cfg,
+		inputs: make([]textinput.Model, 4),
 	}
 
-	cfg := &configuration.Config{}
-	_ = cfg.LoadFromFile()
-
 	var t textinput.Model
 	for i := range m.inputs {
 		t = textinput.New()
@@ -50,8 +60,16 @@ func initialConfigureModel() configureModel {
 		t.Width = 20
 		switch i {
 		case 0:
-			t.Placeholder = "iCal Url"
+			t.Placeholder = "iCal Url or .ics file"
+			// Private feed URLs carry a long secret, so a saved one stays
+			// in the secret store; a file path is shown.
+			t.CharLimit = 512
 			t.SetValue(cfg.CalendarUrl)
+			if cfg.CalendarUrlRef != "" {
+				t.Placeholder = "iCal Url (saved, blank keeps it)"
+				t.EchoMode = textinput.EchoPassword
+				t.EchoCharacter = '•'
+			}
 			t.Focus()
 			t.PromptStyle = focusedStyle
 			t.TextStyle = focusedStyle
@@ -61,16 +79,25 @@ func initialConfigureModel() configureModel {
 			t.SetValue(cfg.JiraEmail)
 			t.CharLimit = 64
 		case 2:
+			// A saved key stays in the secret store; typing a new one
+			// replaces it.
 			t.Placeholder = "Jira API Key"
-			t.SetValue(cfg.JiraToken)
+			if cfg.JiraTokenRef != "" {
+				t.Placeholder = "Jira API Key (saved, blank keeps it)"
+				t.Width = 36
+			}
 			t.EchoMode = textinput.EchoPassword
 			t.EchoCharacter = '•'
+		case 3:
+			t.Placeholder = "Obsidian Vault Path"
+			t.SetValue(cfg.VaultPath)
+			t.CharLimit = 256
 		}
 
 		m.inputs[i] = t
 	}
 
-	return m
+	return m, nil
 }
 
 func (m configureModel) Init() tea.Cmd {
@@ -106,11 +133,17 @@ func (m configureModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Did the user press enter while the submit button was focused?
 			if s == "enter" && m.focusIndex == len(m.inputs) {
 				m.submitted = true
-				cfg := &configuration.Config{
-					CalendarUrl: m.inputs[0].Value(),
-					JiraEmail:   m.inputs[1].Value(),
-					JiraToken:   m.inputs[2].Value(),
+				// Start from the saved config so settings without an input,
+				// such as holidays, survive a reconfigure.
+				cfg := m.cfg
+				if feed := m.inputs[0].Value(); feed != "" || cfg.CalendarUrlRef == "" {
+					cfg.SetFeedURL(feed)
+				}
+				cfg.JiraEmail = m.inputs[1].Value()
+				if token := m.inputs[2].Value(); token != "" {
+					cfg.JiraToken = token
 				}
+				cfg.VaultPath = m.inputs[3].Value()
 				err := cfg.Write()
 				if err != nil {
 					m.err = err
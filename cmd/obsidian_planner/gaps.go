@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type gapsMsg string
+
+// gapsCmd handles "/gaps [when]", listing the free blocks of today or of
+// the given range and what each is good for.
+func (m *chatModel) gapsCmd(when string) tea.Cmd {
+	when = strings.TrimSpace(when)
+	return func() tea.Msg {
+		gaps, err := m.modelInfo.FreeGaps(context.Background(), when)
+		if err != nil {
+			return errMsg(err)
+		}
+		label := when
+		if label == "" {
+			label = "today"
+		}
+		if len(gaps) == 0 {
+			return gapsMsg(fmt.Sprintf("No free time left in working hours %s.", label))
+		}
+		var b strings.Builder
+		b.WriteString(fmt.Sprintf("Free blocks %s:", label))
+		day := ""
+		for _, g := range gaps {
+			if d := g.Start.Format("Monday Jan 2"); d != day && label != "today" {
+				day = d
+				b.WriteString("\n" + d)
+			}
+			b.WriteString("\n  " + g.String())
+		}
+		return gapsMsg(b.String())
+	}
+}
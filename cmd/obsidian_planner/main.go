@@ -1,10 +1,13 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 
+	"obsidian-ai-planner/configuration"
+
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -13,14 +16,59 @@ func main() {
 	if len(os.Args) > 1 {
 		initialMsg = os.Args[1]
 	}
+	switch strings.ToLower(initialMsg) {
+	case "undo":
+		if err := runUndo(os.Args[2:]); err != nil {
+			fmt.Printf("Undo failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	case "auth":
+		if err := runAuth(os.Args[2:]); err != nil {
+			fmt.Printf("Auth failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	case "stats":
+		if err := runStats(os.Args[2:]); err != nil {
+			fmt.Printf("Stats failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 	var p *tea.Program
 	if strings.ToLower(initialMsg) == "configure" {
-		p = tea.NewProgram(initialConfigureModel())
+		configure, err := initialConfigureModel()
+		if err != nil {
+			fmt.Printf("Could not configure: %v\n", err)
+			os.Exit(1)
+		}
+		p = tea.NewProgram(configure)
 	} else {
-		p = tea.NewProgram(initialChatModel(initialMsg))
+		chat, err := initialChatModel(initialMsg)
+		if err != nil {
+			fmt.Printf("Could not start the planner: %v\n", err)
+			os.Exit(1)
+		}
+		p = tea.NewProgram(chat)
 	}
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("Alas, there's been an error: %v", err)
 		os.Exit(1)
 	}
 }
+
+// loadConfig reads the config, which does not exist before the first
+// configure. Secrets that could not be moved out of the file are reported
+// but do not stop the command.
+func loadConfig() (*configuration.Config, error) {
+	cfg := &configuration.Config{}
+	err := cfg.LoadFromFile()
+	switch {
+	case errors.Is(err, configuration.ErrSecretsNotMoved):
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	case err != nil && !errors.Is(err, os.ErrNotExist):
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"obsidian-ai-planner/vault"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	insertStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+	deleteStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	hunkStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
+)
+
+// reviewModel shows the changes a generated plan would make to today's note
+// and lets the user accept or reject them, section by section or hunk by
+// hunk. Nothing is accepted until the user says so.
+type reviewModel struct {
+	note     *vault.DailyNote
+	diffs    []vault.SectionDiff
+	accepted [][]bool
+	section  int
+	hunk     int
+}
+
+type planMsg struct {
+	text  string
+	note  *vault.DailyNote
+	diffs []vault.SectionDiff
+}
+
+type reviewAppliedMsg string
+
+func newReviewModel(msg planMsg) *reviewModel {
+	accepted := make([][]bool, len(msg.diffs))
+	for i, d := range msg.diffs {
+		accepted[i] = make([]bool, len(d.Hunks))
+	}
+	return &reviewModel{note: msg.note, diffs: msg.diffs, accepted: accepted}
+}
+
+// Update handles a key press and reports whether the review is finished and,
+// if so, whether the accepted changes should be written.
+func (r *reviewModel) Update(msg tea.KeyMsg) (done bool, confirmed bool) {
+	switch msg.String() {
+	case "up", "k":
+		r.move(-1)
+	case "down", "j":
+		r.move(1)
+	case "left", "h":
+		if r.section > 0 {
+			r.section--
+			r.hunk = 0
+		}
+	case "right", "l":
+		if r.section < len(r.diffs)-1 {
+			r.section++
+			r.hunk = 0
+		}
+	case "y":
+		r.accepted[r.section][r.hunk] = true
+		r.move(1)
+	case "n":
+		r.accepted[r.section][r.hunk] = false
+		r.move(1)
+	case "a":
+		r.setSection(true)
+	case "r":
+		r.setSection(false)
+	case "enter":
+		return true, true
+	case "esc":
+		return true, false
+	}
+	return false, false
+}
+
+// move steps through the hunks of every section in order.
+func (r *reviewModel) move(step int) {
+	hunk := r.hunk + step
+	switch {
+	case hunk < 0:
+		if r.section > 0 {
+			r.section--
+			r.hunk = len(r.diffs[r.section].Hunks) - 1
+		}
+	case hunk >= len(r.diffs[r.section].Hunks):
+		if r.section < len(r.diffs)-1 {
+			r.section++
+			r.hunk = 0
+		}
+	default:
+		r.hunk = hunk
+	}
+}
+
+func (r *reviewModel) setSection(accept bool) {
+	for i := range r.accepted[r.section] {
+		r.accepted[r.section][i] = accept
+	}
+}
+
+// Updates returns the section bodies to write, based on the note as it was
+// when the plan was made. Sections with nothing accepted are left out so
+// they are not touched at all.
+func (r *reviewModel) Updates() vault.SectionUpdates {
+	var updates vault.SectionUpdates
+	for i, d := range r.diffs {
+		accepted := false
+		for _, a := range r.accepted[i] {
+			accepted = accepted || a
+		}
+		if !accepted {
+			continue
+		}
+		body := d.Body(r.accepted[i])
+		updates.Set(d.Section, &body)
+	}
+	return updates
+}
+
+func (r *reviewModel) View() string {
+	var b strings.Builder
+	d := r.diffs[r.section]
+	b.WriteString(focusedStyle.Render(fmt.Sprintf("Proposed changes to %s (%d/%d)", d.Section, r.section+1, len(r.diffs))))
+	b.WriteString("\n\n")
+	for i, h := range d.Hunks {
+		cursor := "  "
+		if i == r.hunk {
+			cursor = focusedStyle.Render("> ")
+		}
+		state := dropStyle.Render("[reject]")
+		if r.accepted[r.section][i] {
+			state = carryStyle.Render("[accept]")
+		}
+		fmt.Fprintf(&b, "%s%s %s\n", cursor, state, hunkStyle.Render(h.Header()))
+		for _, l := range h.Lines {
+			line := l.String()
+			switch l.Op {
+			case vault.DiffInsert:
+				line = insertStyle.Render(line)
+			case vault.DiffDelete:
+				line = deleteStyle.Render(line)
+			}
+			b.WriteString("    " + line + "\n")
+		}
+	}
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("y/n accept/reject hunk • a/r accept/reject section • ←/→ section • enter write • esc discard"))
+	return b.String()
+}
+
+// summary describes what was accepted.
+func (r *reviewModel) summary() string {
+	var sections []string
+	for i, d := range r.diffs {
+		n := 0
+		for _, a := range r.accepted[i] {
+			if a {
+				n++
+			}
+		}
+		if n > 0 {
+			sections = append(sections, fmt.Sprintf("%s (%d/%d)", d.Section, n, len(d.Hunks)))
+		}
+	}
+	if len(sections) == 0 {
+		return "No changes accepted, today's note was left as it was."
+	}
+	return "Updated " + strings.Join(sections, ", ") + " in " + r.note.Path
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"obsidian-ai-planner/vault"
+)
+
+// runStats prints the task statistics the Dataview tables would show.
+func runStats(args []string) error {
+	flags := flag.NewFlagSet("stats", flag.ContinueOnError)
+	week := flags.Bool("week", false, "show the statistics for the current week")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if !*week {
+		return errors.New("usage: obsidian_planner stats --week")
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	v, err := vault.New(cfg.VaultPath)
+	if err != nil {
+		return err
+	}
+	stats, err := v.WeekStats(time.Now())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Week of %s to %s\n\n%s\n", stats.Start.Format("Mon Jan 2"), stats.End.Format("Mon Jan 2"), stats)
+	return nil
+}
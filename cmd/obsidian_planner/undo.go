@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"obsidian-ai-planner/configuration"
+	"obsidian-ai-planner/vault"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type undoMsg string
+
+// parseUndoCount reads the optional number of changes to undo.
+func parseUndoCount(arg string) (int, error) {
+	arg = strings.TrimSpace(arg)
+	if arg == "" {
+		return 1, nil
+	}
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("undo expects a positive number of changes, got %q", arg)
+	}
+	return n, nil
+}
+
+// undo restores the notes changed by the planner's last n changes, such as
+// a plan or a carryover, and describes what it did.
+func undo(n int) (string, error) {
+	dir, err := configuration.HistoryDir()
+	if err != nil {
+		return "", err
+	}
+	undone, err := vault.NewJournal(dir).Undo(n)
+	var lines []string
+	for _, e := range undone {
+		if e.Backup == "" {
+			lines = append(lines, "Removed "+e.Path)
+		} else {
+			lines = append(lines, "Restored "+e.Path)
+		}
+	}
+	if len(undone) == 0 && err == nil {
+		lines = append(lines, "Nothing to undo.")
+	}
+	return strings.Join(lines, "\n"), err
+}
+
+// runUndo handles "obsidian_planner undo [n]".
+func runUndo(args []string) error {
+	var arg string
+	if len(args) > 0 {
+		arg = args[0]
+	}
+	n, err := parseUndoCount(arg)
+	if err != nil {
+		return err
+	}
+	summary, err := undo(n)
+	if summary != "" {
+		fmt.Println(summary)
+	}
+	return err
+}
+
+func (m *chatModel) undoCmd(arg string) tea.Cmd {
+	return func() tea.Msg {
+		n, err := parseUndoCount(arg)
+		if err != nil {
+			return errMsg(err)
+		}
+		summary, err := undo(n)
+		if err != nil {
+			if summary != "" {
+				err = fmt.Errorf("%s\n%w", summary, err)
+			}
+			return errMsg(err)
+		}
+		return undoMsg(summary)
+	}
+}
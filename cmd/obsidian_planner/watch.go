@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"time"
+
+	"obsidian-ai-planner/vault"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var noticeStyle = lipgloss.NewStyle().Faint(true)
+
+type watchStartedMsg struct {
+	watcher *vault.Watcher
+}
+
+type noteChangedMsg string
+
+type watchErrMsg struct {
+	err error
+}
+
+// watchNotes starts watching today's note and the weekly note covering
+// today, so edits made in Obsidian reach the planner's context.
+func (m *chatModel) watchNotes(today string) tea.Cmd {
+	return func() tea.Msg {
+		paths := []string{today}
+		weekly, err := m.modelInfo.Vault.WeeklyNote(time.Now())
+		if err != nil && !errors.Is(err, vault.ErrNoteNotFound) {
+			return errMsg(err)
+		}
+		if weekly != nil {
+			paths = append(paths, weekly.Path)
+		}
+		w, err := m.modelInfo.Vault.Watch(paths...)
+		if err != nil {
+			return errMsg(err)
+		}
+		return watchStartedMsg{watcher: w}
+	}
+}
+
+// waitForNoteChange delivers the next change the watcher reports. It is
+// issued again after every change.
+func waitForNoteChange(w *vault.Watcher) tea.Cmd {
+	return func() tea.Msg {
+		select {
+		case path, ok := <-w.Changes:
+			if !ok {
+				return nil
+			}
+			return noteChangedMsg(path)
+		case err := <-w.Errors:
+			return watchErrMsg{err: err}
+		}
+	}
+}
+
+func noteChangedNotice(path string) string {
+	return noticeStyle.Render(filepath.Base(path) + " changed, context refreshed")
+}
@@ -2,29 +2,197 @@ package configuration
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
+	"slices"
+	"time"
+
+	"obsidian-ai-planner/secrets"
 )
 
 var configLocation string = "./config.json"
 
+// ErrSecretsNotMoved reports that the config loaded but plaintext secrets
+// in it could not be moved to the secret store.
+var ErrSecretsNotMoved = errors.New("plaintext secrets in the config were not moved to the secret store")
+
 type Config struct {
-	CalendarUrl string `json:"calendar_url"`
-	JiraEmail   string `json:"jira_email"`
-	JiraToken   string `json:"jira_token"`
+	// CalendarUrl is an iCal feed URL not yet in the secret store, or an
+	// .ics file; read it with FeedURL. CalendarUrlRef names a feed URL in
+	// the secret store.
+	CalendarUrl    string `json:"calendar_url,omitempty"`
+	CalendarUrlRef string `json:"calendar_url_ref,omitempty"`
+	JiraEmail      string `json:"jira_email"`
+	// JiraToken is a token not yet in the secret store: one typed into the
+	// configure screen, or read from an older config file. Write moves it
+	// to the store, so it is never saved here; read it with JiraAPIToken.
+	JiraToken string `json:"jira_token,omitempty"`
+	// JiraTokenRef names the Jira API token in the secret store.
+	JiraTokenRef string   `json:"jira_token_ref,omitempty"`
+	VaultPath    string   `json:"vault_path"`
+	Holidays     []string `json:"holidays"`
+	// Email is our address in calendar invitations, used to find our RSVP
+	// in ICS and CalDAV calendars. JiraEmail is tried too.
+	Email string `json:"email,omitempty"`
+	// Calendars are further calendar sources, used alongside CalendarUrl.
+	Calendars []CalendarConfig `json:"calendars,omitempty"`
+
+	// WorkStart and WorkEnd are the working hours as HH:MM.
+	WorkStart string `json:"work_start,omitempty"`
+	WorkEnd   string `json:"work_end,omitempty"`
+	// MeetingOverhead is the preparation and follow-up a meeting costs, as
+	// a fraction of its length.
+	MeetingOverhead *float64 `json:"meeting_overhead,omitempty"`
+	// SwitchMinutes is the time lost getting back into work after each
+	// meeting.
+	SwitchMinutes *int `json:"switch_minutes,omitempty"`
+
+	// SecretStore is where tokens and passwords are kept: "keyring" for
+	// the OS keyring, "pass" for the pass password manager, "file" for
+	// SecretsFile, or empty for the keyring when one is available and
+	// SecretsFile otherwise.
+	SecretStore string `json:"secret_store,omitempty"`
+	// SecretsFile is the passphrase-encrypted secrets file. Relative paths
+	// are under ~/.planner; secrets.age by default.
+	SecretsFile string `json:"secrets_file,omitempty"`
+	// GoogleCredentials is the OAuth client file from the Google Cloud
+	// console. Relative paths are under ~/.planner; credentials.json by
+	// default.
+	GoogleCredentials string `json:"google_credentials,omitempty"`
+
+	// store is the secret store once opened; tests set it.
+	store secrets.Store
+}
+
+// CalendarConfig is one calendar source. Type is "google", "ics" or
+// "caldav"; URL is the feed or collection and Username and PasswordRef are
+// the CalDAV login, the password being kept in the secret store. An ICS
+// feed URL is a secret too and moves to URLRef. Password is only read from
+// older config files. CalendarIDs are the Google calendars to read, the
+// primary calendar when empty.
+type CalendarConfig struct {
+	Type        string   `json:"type"`
+	URL         string   `json:"url,omitempty"`
+	URLRef      string   `json:"url_ref,omitempty"`
+	Username    string   `json:"username,omitempty"`
+	Password    string   `json:"password,omitempty"`
+	PasswordRef string   `json:"password_ref,omitempty"`
+	CalendarIDs []string `json:"calendar_ids,omitempty"`
+}
+
+// Defaults used when the working hours and overheads are not configured.
+const (
+	DefaultWorkStart       = "09:00"
+	DefaultWorkEnd         = "17:00"
+	DefaultMeetingOverhead = 0.25
+	DefaultSwitchMinutes   = 10
+)
+
+// WorkingHours returns the start and end of the working day as offsets from
+// midnight, falling back to the defaults for entries that do not parse or
+// that would make an empty day.
+func (c *Config) WorkingHours() (time.Duration, time.Duration) {
+	start, ok := clockOffset(c.WorkStart)
+	if !ok {
+		start, _ = clockOffset(DefaultWorkStart)
+	}
+	end, ok := clockOffset(c.WorkEnd)
+	if !ok {
+		end, _ = clockOffset(DefaultWorkEnd)
+	}
+	if end <= start {
+		start, _ = clockOffset(DefaultWorkStart)
+		end, _ = clockOffset(DefaultWorkEnd)
+	}
+	return start, end
+}
+
+// Overheads returns the meeting overhead factor and the minutes lost to each
+// context switch, using the defaults for anything unset or negative.
+func (c *Config) Overheads() (float64, int) {
+	overhead, switchMinutes := DefaultMeetingOverhead, DefaultSwitchMinutes
+	if c.MeetingOverhead != nil && *c.MeetingOverhead >= 0 {
+		overhead = *c.MeetingOverhead
+	}
+	if c.SwitchMinutes != nil && *c.SwitchMinutes >= 0 {
+		switchMinutes = *c.SwitchMinutes
+	}
+	return overhead, switchMinutes
 }
 
+// CalendarEmails returns the addresses that identify us in invitations.
+func (c *Config) CalendarEmails() []string {
+	var emails []string
+	for _, e := range []string{c.Email, c.JiraEmail} {
+		if e != "" && !slices.Contains(emails, e) {
+			emails = append(emails, e)
+		}
+	}
+	return emails
+}
+
+func clockOffset(s string) (time.Duration, bool) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, true
+}
+
+// HolidayDates returns the configured holidays, skipping any entry that is
+// not a YYYY-MM-DD date.
+func (c *Config) HolidayDates() []time.Time {
+	var dates []time.Time
+	for _, h := range c.Holidays {
+		d, err := time.ParseInLocation("2006-01-02", h, time.Local)
+		if err != nil {
+			continue
+		}
+		dates = append(dates, d)
+	}
+	return dates
+}
+
+// Write saves the config, first moving any plaintext secret into the
+// secret store. It fails rather than write a secret to the file.
 func (c *Config) Write() error {
+	if err := c.moveSecrets(); err != nil {
+		return fmt.Errorf("storing secrets: %w", err)
+	}
+	return c.writeFile()
+}
+
+func (c *Config) writeFile() error {
 	data, err := json.MarshalIndent(c, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(configLocation, data, 0644)
+	if err := os.WriteFile(configLocation, data, 0600); err != nil {
+		return err
+	}
+	// WriteFile keeps the mode of an existing file, which older versions
+	// created world-readable.
+	return os.Chmod(configLocation, 0600)
 }
 
+// LoadFromFile reads the config. Secrets left in plaintext by older
+// versions are moved to the secret store and the file rewritten; if the
+// store cannot be reached they stay in memory, the move is tried again
+// next time, and the error wraps ErrSecretsNotMoved. The config is usable
+// after that error.
 func (c *Config) LoadFromFile() error {
 	data, err := os.ReadFile(configLocation)
 	if err != nil {
 		return err
 	}
-	return json.Unmarshal(data, c)
+	if err := json.Unmarshal(data, c); err != nil {
+		return err
+	}
+	if c.hasPlaintextSecrets() {
+		if err := c.Write(); err != nil {
+			return fmt.Errorf("%w: %w", ErrSecretsNotMoved, err)
+		}
+	}
+	return nil
 }
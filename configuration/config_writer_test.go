@@ -2,7 +2,9 @@ package configuration
 
 import (
 	"os"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestConfig_WriteAndLoad(t *testing.T) {
@@ -15,10 +17,13 @@ func TestConfig_WriteAndLoad(t *testing.T) {
 		os.Remove(tempFile)
 	}()
 
+	store := memorySecrets{}
 	cfg := &Config{
 		CalendarUrl: "https://example.com/cal.ics",
 		JiraEmail:   "test@example.com",
 		JiraToken:   "secret_token",
+		VaultPath:   "/home/test/Obsidian",
+		store:       store,
 	}
 
 	// Test Write
@@ -27,27 +32,37 @@ func TestConfig_WriteAndLoad(t *testing.T) {
 		t.Fatalf("Failed to write config: %v", err)
 	}
 
-	// Verify file exists
-	if _, err := os.Stat(tempFile); os.IsNotExist(err) {
+	// Verify file exists, readable only by us and without the token
+	data, err := os.ReadFile(tempFile)
+	if err != nil {
 		t.Fatal("Config file was not created")
 	}
+	if strings.Contains(string(data), "secret_token") || strings.Contains(string(data), "cal.ics") {
+		t.Errorf("Expected the token and feed URL to stay out of the config file, got %s", data)
+	}
+	if info, _ := os.Stat(tempFile); info.Mode().Perm() != 0600 {
+		t.Errorf("Expected mode 0600, got %v", info.Mode().Perm())
+	}
 
 	// Test LoadFromFile
-	newCfg := &Config{}
+	newCfg := &Config{store: store}
 	err = newCfg.LoadFromFile()
 	if err != nil {
 		t.Fatalf("Failed to load config: %v", err)
 	}
 
 	// Verify data
-	if newCfg.CalendarUrl != cfg.CalendarUrl {
-		t.Errorf("Expected CalendarUrl %s, got %s", cfg.CalendarUrl, newCfg.CalendarUrl)
+	if feed, err := newCfg.FeedURL(); feed != "https://example.com/cal.ics" {
+		t.Errorf("Expected CalendarUrl https://example.com/cal.ics from the secret store, got %s (%v)", feed, err)
 	}
 	if newCfg.JiraEmail != cfg.JiraEmail {
 		t.Errorf("Expected JiraEmail %s, got %s", cfg.JiraEmail, newCfg.JiraEmail)
 	}
-	if newCfg.JiraToken != cfg.JiraToken {
-		t.Errorf("Expected JiraToken %s, got %s", cfg.JiraToken, newCfg.JiraToken)
+	if token, err := newCfg.JiraAPIToken(); token != "secret_token" {
+		t.Errorf("Expected JiraToken secret_token from the secret store, got %s (%v)", token, err)
+	}
+	if newCfg.VaultPath != cfg.VaultPath {
+		t.Errorf("Expected VaultPath %s, got %s", cfg.VaultPath, newCfg.VaultPath)
 	}
 }
 
@@ -66,3 +81,49 @@ func TestConfig_LoadFromFile_NotFound(t *testing.T) {
 		t.Error("Expected error when loading non-existent file, got nil")
 	}
 }
+
+func TestConfig_HolidayDates(t *testing.T) {
+	cfg := &Config{Holidays: []string{"2024-12-25", "not a date", "2025-01-01"}}
+	dates := cfg.HolidayDates()
+	if len(dates) != 2 {
+		t.Fatalf("Expected 2 holidays, got %d", len(dates))
+	}
+	if dates[0].Format("2006-01-02") != "2024-12-25" || dates[1].Format("2006-01-02") != "2025-01-01" {
+		t.Errorf("Unexpected holidays: %v", dates)
+	}
+}
+
+func TestConfig_WorkingHours(t *testing.T) {
+	tests := []struct {
+		start, end string
+		wantStart  time.Duration
+		wantEnd    time.Duration
+	}{
+		{"", "", 9 * time.Hour, 17 * time.Hour},
+		{"08:30", "16:00", 8*time.Hour + 30*time.Minute, 16 * time.Hour},
+		{"8am", "18:00", 9 * time.Hour, 18 * time.Hour},
+		{"18:00", "09:00", 9 * time.Hour, 17 * time.Hour},
+	}
+	for _, tt := range tests {
+		cfg := &Config{WorkStart: tt.start, WorkEnd: tt.end}
+		start, end := cfg.WorkingHours()
+		if start != tt.wantStart || end != tt.wantEnd {
+			t.Errorf("WorkingHours(%q, %q): expected %v-%v, got %v-%v", tt.start, tt.end, tt.wantStart, tt.wantEnd, start, end)
+		}
+	}
+}
+
+func TestConfig_Overheads(t *testing.T) {
+	cfg := &Config{}
+	overhead, switchMinutes := cfg.Overheads()
+	if overhead != DefaultMeetingOverhead || switchMinutes != DefaultSwitchMinutes {
+		t.Errorf("Expected defaults, got %v and %d", overhead, switchMinutes)
+	}
+
+	zero, five := 0.0, 5
+	cfg = &Config{MeetingOverhead: &zero, SwitchMinutes: &five}
+	overhead, switchMinutes = cfg.Overheads()
+	if overhead != 0 || switchMinutes != 5 {
+		t.Errorf("Expected 0 and 5, got %v and %d", overhead, switchMinutes)
+	}
+}
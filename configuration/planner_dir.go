@@ -0,0 +1,35 @@
+package configuration
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// PlannerDir returns ~/.planner, where the planner keeps state that does not
+// belong in the vault, such as the undo history.
+func PlannerDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".planner"), nil
+}
+
+// HistoryDir returns the directory of the undo journal.
+func HistoryDir() (string, error) {
+	dir, err := PlannerDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "history"), nil
+}
+
+// CalendarCachePath returns the file holding the local copy of the Google
+// calendars.
+func CalendarCachePath() (string, error) {
+	dir, err := PlannerDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "calendar-cache.json"), nil
+}
@@ -0,0 +1,247 @@
+package configuration
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"obsidian-ai-planner/secrets"
+)
+
+// Default file names under ~/.planner.
+const (
+	DefaultSecretsFile       = "secrets.age"
+	DefaultGoogleCredentials = "credentials.json"
+)
+
+// Secrets opens the configured secret store, once, so a passphrase is
+// asked for at most once. When none is configured the default is chosen
+// and saved, so a later session without a keyring, such as one over SSH,
+// still looks where the secrets went.
+func (c *Config) Secrets() (secrets.Store, error) {
+	if c.store != nil {
+		return c.store, nil
+	}
+	path, err := plannerPath(c.SecretsFile, DefaultSecretsFile)
+	if err != nil {
+		return nil, err
+	}
+	if c.SecretStore == "" {
+		c.SecretStore = secrets.DefaultKind()
+		if err := saveSecretStore(c.SecretStore); err != nil {
+			return nil, fmt.Errorf("saving the secret store choice: %w", err)
+		}
+	}
+	store, err := secrets.Open(c.SecretStore, path)
+	if err != nil {
+		return nil, err
+	}
+	c.store = store
+	return store, nil
+}
+
+// GoogleCredentialsPath returns the Google OAuth client file. Without a
+// configured path it is ~/.planner/credentials.json, or credentials.json in
+// the working directory where earlier versions read it, if only that one
+// exists.
+func (c *Config) GoogleCredentialsPath() (string, error) {
+	path, err := plannerPath(c.GoogleCredentials, DefaultGoogleCredentials)
+	if err != nil || c.GoogleCredentials != "" {
+		return path, err
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		if _, err := os.Stat(DefaultGoogleCredentials); err == nil {
+			return DefaultGoogleCredentials, nil
+		}
+	}
+	return path, nil
+}
+
+// plannerPath resolves a configured path, relative ones and the default
+// name being under ~/.planner.
+func plannerPath(configured, name string) (string, error) {
+	if filepath.IsAbs(configured) {
+		return configured, nil
+	}
+	if configured != "" {
+		name = configured
+	}
+	dir, err := PlannerDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// Names of secrets in the secret store.
+const (
+	JiraTokenSecret = "jira-token"
+	FeedURLSecret   = "calendar-url"
+)
+
+// JiraAPIToken returns the Jira API token, or "" when none is configured.
+func (c *Config) JiraAPIToken() (string, error) {
+	return c.secret(c.JiraToken, c.JiraTokenRef)
+}
+
+// UnlockSecrets opens the secret store and asks for its passphrase, if it
+// has one, so nothing has to prompt later while a full-screen program owns
+// the terminal.
+func (c *Config) UnlockSecrets() error {
+	store, err := c.Secrets()
+	if err != nil {
+		return err
+	}
+	if u, ok := store.(secrets.Unlocker); ok {
+		return u.Unlock()
+	}
+	return nil
+}
+
+// FeedURL returns the iCal feed or .ics file of CalendarUrl, or "" when
+// none is configured. A private feed's URL holds its secret, so it is kept
+// in the secret store; a file path is not.
+func (c *Config) FeedURL() (string, error) {
+	return c.secret(c.CalendarUrl, c.CalendarUrlRef)
+}
+
+// SetFeedURL replaces the iCal feed, which Write moves to the secret store
+// when it is a URL.
+func (c *Config) SetFeedURL(feed string) {
+	c.CalendarUrl, c.CalendarUrlRef = feed, ""
+}
+
+// ICSURL returns the feed or file of an ICS calendar.
+func (c *Config) ICSURL(cal CalendarConfig) (string, error) {
+	return c.secret(cal.URL, cal.URLRef)
+}
+
+// CalDAVPassword returns the password of a CalDAV calendar.
+func (c *Config) CalDAVPassword(cal CalendarConfig) (string, error) {
+	return c.secret(cal.Password, cal.PasswordRef)
+}
+
+func (c *Config) secret(plain, ref string) (string, error) {
+	if plain != "" || ref == "" {
+		return plain, nil
+	}
+	store, err := c.Secrets()
+	if err != nil {
+		return "", err
+	}
+	value, err := store.Get(ref)
+	if errors.Is(err, secrets.ErrNotFound) {
+		return "", fmt.Errorf("secret %q is missing from the secret store, run `obsidian_planner configure`", ref)
+	}
+	return value, err
+}
+
+func (c *Config) hasPlaintextSecrets() bool {
+	if c.JiraToken != "" || isFeedURL(c.CalendarUrl) {
+		return true
+	}
+	for _, cal := range c.Calendars {
+		if cal.Password != "" || strings.EqualFold(cal.Type, "ics") && isFeedURL(cal.URL) {
+			return true
+		}
+	}
+	return false
+}
+
+// isFeedURL tells a feed URL, which may carry a secret, from a file path.
+func isFeedURL(s string) bool {
+	return strings.Contains(s, "://") && !strings.HasPrefix(s, "file://")
+}
+
+// moveSecrets puts every plaintext secret in the secret store and keeps
+// only its name in the config.
+func (c *Config) moveSecrets() error {
+	if !c.hasPlaintextSecrets() {
+		return nil
+	}
+	store, err := c.Secrets()
+	if err != nil {
+		return err
+	}
+	if c.JiraToken != "" {
+		if err := store.Set(JiraTokenSecret, c.JiraToken); err != nil {
+			return err
+		}
+		c.JiraToken, c.JiraTokenRef = "", JiraTokenSecret
+	}
+	if isFeedURL(c.CalendarUrl) {
+		if err := store.Set(FeedURLSecret, c.CalendarUrl); err != nil {
+			return err
+		}
+		c.CalendarUrl, c.CalendarUrlRef = "", FeedURLSecret
+	}
+	for i := range c.Calendars {
+		cal := &c.Calendars[i]
+		if strings.EqualFold(cal.Type, "ics") && isFeedURL(cal.URL) {
+			name := icsSecret(cal.URL)
+			if err := store.Set(name, cal.URL); err != nil {
+				return err
+			}
+			cal.URL, cal.URLRef = "", name
+		}
+		if cal.Password != "" {
+			name := calDAVSecret(*cal)
+			if err := store.Set(name, cal.Password); err != nil {
+				return err
+			}
+			cal.Password, cal.PasswordRef = "", name
+		}
+	}
+	return nil
+}
+
+// icsSecret names a feed URL by a hash of it, as the URL itself is the
+// secret.
+func icsSecret(feed string) string {
+	sum := sha256.Sum256([]byte(feed))
+	return "ics-" + hex.EncodeToString(sum[:6])
+}
+
+// calDAVSecret names a CalDAV password after the server and user, using
+// only characters every store accepts in a name.
+func calDAVSecret(cal CalendarConfig) string {
+	host := cal.URL
+	if u, err := url.Parse(cal.URL); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	name := "caldav-" + cal.Username + "@" + host
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("@.-_", r) {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// saveSecretStore records the chosen store in the config file, leaving the
+// rest of the file as it is. Without a file the choice is saved by the
+// next Write.
+func saveSecretStore(kind string) error {
+	saved := &Config{}
+	data, err := os.ReadFile(configLocation)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, saved); err != nil {
+		return err
+	}
+	if saved.SecretStore != "" {
+		return nil
+	}
+	saved.SecretStore = kind
+	return saved.writeFile()
+}
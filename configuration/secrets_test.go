@@ -0,0 +1,168 @@
+package configuration
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"obsidian-ai-planner/secrets"
+)
+
+func TestGoogleCredentialsPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Chdir(t.TempDir())
+	planner := filepath.Join(home, ".planner", "credentials.json")
+
+	cfg := &Config{}
+	if got, _ := cfg.GoogleCredentialsPath(); got != planner {
+		t.Errorf("Expected %s by default, got %s", planner, got)
+	}
+
+	// Earlier versions read credentials.json from the working directory.
+	if err := os.WriteFile("credentials.json", []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := cfg.GoogleCredentialsPath(); got != "credentials.json" {
+		t.Errorf("Expected the legacy file, got %s", got)
+	}
+
+	cfg.GoogleCredentials = "google/client.json"
+	if got, _ := cfg.GoogleCredentialsPath(); got != filepath.Join(home, ".planner", "google", "client.json") {
+		t.Errorf("Expected a relative path under ~/.planner, got %s", got)
+	}
+	cfg.GoogleCredentials = "/etc/planner/client.json"
+	if got, _ := cfg.GoogleCredentialsPath(); got != "/etc/planner/client.json" {
+		t.Errorf("Expected the absolute path, got %s", got)
+	}
+}
+
+type memorySecrets map[string]string
+
+func (m memorySecrets) Get(name string) (string, error) {
+	v, ok := m[name]
+	if !ok {
+		return "", secrets.ErrNotFound
+	}
+	return v, nil
+}
+func (m memorySecrets) Set(name, value string) error { m[name] = value; return nil }
+func (m memorySecrets) Delete(name string) error     { delete(m, name); return nil }
+
+func TestLoadFromFile_MovesPlaintextSecrets(t *testing.T) {
+	oldLocation := configLocation
+	configLocation = filepath.Join(t.TempDir(), "config.json")
+	defer func() { configLocation = oldLocation }()
+
+	legacy := `{
+  "calendar_url": "https://calendar.google.com/calendar/ical/alice/private-abc123/basic.ics",
+  "jira_email": "alice@example.com",
+  "jira_token": "jira-plaintext",
+  "vault_path": "/vault",
+  "holidays": null,
+  "calendars": [{"type": "caldav", "url": "https://dav.example.com/alice/work/", "username": "alice", "password": "dav-plaintext"},
+    {"type": "ICS", "url": "https://outlook.office365.com/owa/calendar/private-def456/calendar.ics"},
+    {"type": "ics", "url": "holidays.ics"}]
+}`
+	if err := os.WriteFile(configLocation, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := memorySecrets{}
+	cfg := &Config{store: store}
+	if err := cfg.LoadFromFile(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(configLocation)
+	if strings.Contains(string(data), "plaintext") || strings.Contains(string(data), "private-") {
+		t.Errorf("Expected the secrets to leave the config file, got %s", data)
+	}
+	if info, _ := os.Stat(configLocation); info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the config to become 0600, got %v", info.Mode().Perm())
+	}
+	if cfg.JiraTokenRef != JiraTokenSecret || store[JiraTokenSecret] != "jira-plaintext" {
+		t.Errorf("Expected the Jira token in the store, got ref %q and %v", cfg.JiraTokenRef, store)
+	}
+	if token, _ := cfg.JiraAPIToken(); token != "jira-plaintext" {
+		t.Errorf("Expected the Jira token back, got %q", token)
+	}
+	cal := cfg.Calendars[0]
+	if cal.PasswordRef != "caldav-alice@dav.example.com" || cal.Password != "" {
+		t.Errorf("Expected the CalDAV password to be referenced, got %+v", cal)
+	}
+	if password, _ := cfg.CalDAVPassword(cal); password != "dav-plaintext" {
+		t.Errorf("Expected the CalDAV password back, got %q", password)
+	}
+
+	if feed, _ := cfg.FeedURL(); !strings.Contains(feed, "private-abc123") || cfg.CalendarUrlRef != FeedURLSecret {
+		t.Errorf("Expected the feed URL in the store, got %q under %q", feed, cfg.CalendarUrlRef)
+	}
+	if feed, _ := cfg.ICSURL(cfg.Calendars[1]); !strings.Contains(feed, "private-def456") || cfg.Calendars[1].URL != "" {
+		t.Errorf("Expected the ICS feed URL in the store, got %q and %+v", feed, cfg.Calendars[1])
+	}
+	if cfg.Calendars[2].URL != "holidays.ics" || cfg.Calendars[2].URLRef != "" {
+		t.Errorf("Expected a file path to stay in the config, got %+v", cfg.Calendars[2])
+	}
+
+	// A missing secret is reported, not treated as an empty password.
+	delete(store, cal.PasswordRef)
+	if _, err := cfg.CalDAVPassword(cal); err == nil {
+		t.Error("Expected an error for a secret missing from the store")
+	}
+}
+
+type lockedSecrets struct{ memorySecrets }
+
+func (lockedSecrets) Set(name, value string) error { return errors.New("keyring locked") }
+
+func TestLoadFromFile_ReportsSecretsNotMoved(t *testing.T) {
+	oldLocation := configLocation
+	configLocation = filepath.Join(t.TempDir(), "config.json")
+	defer func() { configLocation = oldLocation }()
+	if err := os.WriteFile(configLocation, []byte(`{"jira_token": "jira-plaintext", "vault_path": "/vault"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &Config{store: lockedSecrets{memorySecrets{}}}
+	if err := cfg.LoadFromFile(); !errors.Is(err, ErrSecretsNotMoved) {
+		t.Errorf("Expected ErrSecretsNotMoved, got %v", err)
+	}
+	if cfg.VaultPath != "/vault" {
+		t.Errorf("Expected the config to load anyway, got %+v", cfg)
+	}
+	if token, _ := cfg.JiraAPIToken(); token != "jira-plaintext" {
+		t.Errorf("Expected the token kept in memory, got %q", token)
+	}
+}
+
+func TestSecrets_SavesDefaultStore(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	oldLocation := configLocation
+	configLocation = filepath.Join(t.TempDir(), "config.json")
+	defer func() { configLocation = oldLocation }()
+	if err := os.WriteFile(configLocation, []byte(`{"vault_path": "/vault"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &Config{}
+	if err := cfg.LoadFromFile(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cfg.Secrets(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.SecretStore == "" {
+		t.Fatal("Expected a store to be chosen")
+	}
+
+	saved := &Config{}
+	if err := saved.LoadFromFile(); err != nil {
+		t.Fatal(err)
+	}
+	if saved.SecretStore != cfg.SecretStore || saved.VaultPath != "/vault" {
+		t.Errorf("Expected the choice %q saved beside the settings, got %+v", cfg.SecretStore, saved)
+	}
+}
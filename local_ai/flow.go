@@ -2,18 +2,34 @@ package local_ai
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"obsidian-ai-planner/calendar"
+	"obsidian-ai-planner/capacity"
+	"obsidian-ai-planner/configuration"
+	"obsidian-ai-planner/vault"
+	"os"
+	"sync"
 	"time"
 
 	"github.com/firebase/genkit/go/ai"
 	"github.com/firebase/genkit/go/genkit"
 )
 
+// contextTTL bounds how long fetched context is reused when no note change
+// invalidates it, so calendar changes still come through.
+const contextTTL = 5 * time.Minute
+
 type ModelInfo struct {
 	GenKit   *genkit.Genkit
 	Model    ai.Model
-	Calendar *calendar.GoogleCalendarIntegration
+	Calendar calendar.Provider
+	Vault    *vault.Vault
+	Config   *configuration.Config
+
+	mu        sync.Mutex
+	cached    *InternalPlannerContext
+	fetchedAt time.Time
 }
 
 type Message struct {
@@ -27,42 +43,215 @@ type PlannerInput struct {
 }
 
 type InternalPlannerContext struct {
-	WeeklyGoals  string           `json:"weeklyGoals"`
-	Calendar     []calendar.Event `json:"calendar"`
-	JiraTickets  []string         `json:"jiraTickets"`
-	CurrentTasks []string         `json:"currentTasks"`
+	WeeklyGoals   []string             `json:"weeklyGoals"`
+	Calendar      []calendar.Event     `json:"calendar"`
+	JiraTickets   []string             `json:"jiraTickets"`
+	CurrentTasks  []*vault.Task        `json:"currentTasks"`
+	DeferredTasks []vault.DeferredTask `json:"deferredTasks"`
+	Annotations   vault.Annotations    `json:"annotations"`
+	History       *vault.Stats         `json:"history"`
+	CapacityTrend capacity.Trend       `json:"capacityTrend"`
+	Capacity      *capacity.Capacity   `json:"capacity"`
+	FreeGaps      []calendar.Gap       `json:"freeGaps"`
+	// CalendarFreshness says whether the events are live or a cached copy,
+	// and flags a copy that is out of date.
+	CalendarFreshness string `json:"calendarFreshness"`
+}
+
+// InvalidateContext drops the cached context so the next request reads the
+// notes and calendar again.
+func (m *ModelInfo) InvalidateContext() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cached = nil
 }
 
 func (m *ModelInfo) fetchContext(ctx context.Context) (*InternalPlannerContext, error) {
-	// TODO: Pull from Obsidian
-	weeklyGoals := "Plan for project unicorn, Review roadmap, Improve test coverage 10%"
-	// TODO: Pull from Daily Note
-	currentTasks := []string{}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.cached != nil && time.Since(m.fetchedAt) < contextTTL {
+		return m.cached, nil
+	}
+	pContext, err := m.loadContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.cached = pContext
+	m.fetchedAt = time.Now()
+	return pContext, nil
+}
+
+func (m *ModelInfo) loadContext(ctx context.Context) (*InternalPlannerContext, error) {
+	var weeklyGoals []string
+	var currentTasks []*vault.Task
+	var deferredTasks []vault.DeferredTask
+	var annotations vault.Annotations
+	var history *vault.Stats
+	var trend capacity.Trend
+	if m.Vault != nil {
+		goals, err := m.Vault.WeeklyGoals(time.Now())
+		if err != nil && !errors.Is(err, vault.ErrNoteNotFound) {
+			return nil, err
+		}
+		weeklyGoals = goals
+
+		tasks, err := m.currentTasks()
+		if err != nil {
+			return nil, err
+		}
+		currentTasks = tasks
+
+		deferred, err := m.Vault.DeferredTasks(time.Now())
+		if err != nil {
+			return nil, err
+		}
+		deferredTasks = deferred
+
+		annotations, err = m.Vault.WeekAnnotations(time.Now())
+		if err != nil {
+			return nil, err
+		}
+
+		// The last seven days, as the Dataview tables would count them.
+		yesterday := time.Now().AddDate(0, 0, -1)
+		history, err = m.Vault.Stats(yesterday.AddDate(0, 0, -6), yesterday)
+		if err != nil {
+			return nil, err
+		}
+
+		trend, err = capacity.RecentTrend(m.Vault, time.Now())
+		if err != nil {
+			return nil, err
+		}
+	}
 	// TODO: Pull from Jira
 	jiraTickets := []string{"Jira-123: Update db", "Jira-456: Fix bug on backend"}
 
 	var calendarEvents []calendar.Event
+	var dayCapacity *capacity.Capacity
+	var gaps []calendar.Gap
+	freshness := "no calendar connected"
 	if m.Calendar != nil {
 		// Today's date helper
 		now := time.Now()
 		year, month, day := now.Date()
 		today := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
 
-		events, err := m.Calendar.GetCalendarEvents(today)
+		events, err := m.Calendar.EventsBetween(today, today.AddDate(0, 0, 1))
 		if err != nil {
 			return nil, err
 		}
 		calendarEvents = events
+
+		settings := m.capacitySettings()
+		c := capacity.Calculate(today, events, settings)
+		dayCapacity = &c
+		gaps = c.FreeGaps()
+		freshness = calendarFreshness(calendar.SyncedAt(m.Calendar), now)
+		if err := calendar.Err(m.Calendar); err != nil {
+			freshness += "; " + err.Error()
+		}
 	}
 
 	return &InternalPlannerContext{
-		WeeklyGoals:  weeklyGoals,
-		Calendar:     calendarEvents,
-		JiraTickets:  jiraTickets,
-		CurrentTasks: currentTasks,
+		WeeklyGoals:   weeklyGoals,
+		Calendar:      calendarEvents,
+		JiraTickets:   jiraTickets,
+		CurrentTasks:  currentTasks,
+		DeferredTasks: deferredTasks,
+		Annotations:   annotations,
+		History:       history,
+		CapacityTrend: trend,
+		Capacity:      dayCapacity,
+		FreeGaps:      gaps,
+
+		CalendarFreshness: freshness,
 	}, nil
 }
 
+// FreeGaps returns the free blocks within working hours over a range such
+// as "today", "tomorrow" or "this week"; see calendar.ParseRange. Weekends
+// are skipped in ranges longer than a day.
+func (m *ModelInfo) FreeGaps(ctx context.Context, when string) ([]calendar.Gap, error) {
+	if m.Calendar == nil {
+		return nil, errors.New("no calendar connected")
+	}
+	now := time.Now()
+	start, end, err := calendar.ParseRange(when, now)
+	if err != nil {
+		return nil, err
+	}
+	if today, _ := calendar.Day(now); start.Equal(today) && end.Equal(today.AddDate(0, 0, 1)) {
+		pContext, err := m.fetchContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return pContext.FreeGaps, nil
+	}
+
+	events, err := m.Calendar.EventsBetween(start, end)
+	if err != nil {
+		return nil, err
+	}
+	settings := m.capacitySettings()
+	var gaps []calendar.Gap
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		if end.Sub(start) > 24*time.Hour && (day.Weekday() == time.Saturday || day.Weekday() == time.Sunday) {
+			continue
+		}
+		c := capacity.Calculate(day, events, settings)
+		gaps = append(gaps, c.FreeGaps()...)
+	}
+	return gaps, nil
+}
+
+// calendarFreshness describes how current events synced at syncedAt are;
+// a zero time means they were read live.
+func calendarFreshness(syncedAt, now time.Time) string {
+	age := now.Sub(syncedAt)
+	switch {
+	case syncedAt.IsZero():
+		return "live"
+	case age > calendar.StaleAfter:
+		return fmt.Sprintf("STALE, last synced %s (%s ago); meetings may have changed since", syncedAt.Format("Mon 15:04"), age.Round(time.Minute))
+	default:
+		return "cached, synced " + syncedAt.Format("15:04")
+	}
+}
+
+// capacitySettings reads the working hours and overheads from the config.
+func (m *ModelInfo) capacitySettings() capacity.Settings {
+	cfg := m.Config
+	if cfg == nil {
+		cfg = &configuration.Config{}
+	}
+	start, end := cfg.WorkingHours()
+	overhead, switchMinutes := cfg.Overheads()
+	return capacity.Settings{
+		WorkStart:       start,
+		WorkEnd:         end,
+		MeetingOverhead: overhead,
+		SwitchMinutes:   switchMinutes,
+	}
+}
+
+// currentTasks reads the tasks already in today's daily note. A missing note
+// simply means nothing has been committed to yet.
+func (m *ModelInfo) currentTasks() ([]*vault.Task, error) {
+	path, err := m.Vault.DailyNotePath(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	note, err := m.Vault.ReadDailyNote(path)
+	if errors.Is(err, os.ErrNotExist) || errors.Is(err, vault.ErrSectionNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return note.Tasks(), nil
+}
+
 func (m *ModelInfo) Chat(ctx context.Context, input PlannerInput) (string, error) {
 	pContext, err := m.fetchContext(ctx)
 	if err != nil {
@@ -82,18 +271,23 @@ Planned work + context switching + cognitive overhead exceeds realistic daily ca
 
 You are allowed to:
 - Estimate task effort when no duration is provided
-- Make reasonable assumptions about a standard workday
-- Apply an overhead factor for meetings and context switching (state your assumptions)
 - Be uncertain but still decisive
 
+You must:
+- Take free time, focus blocks and fragmentation from the computed capacity below; do not redo that arithmetic
+- Only suggest Jira work for deep work blocks, and name the ticket and the block it fits (for example "Jira-123 fits 13:00-15:30")
+- Offer quick administrative tasks, not Jira work, for admin blocks
+
 You are encouraged to:
 - Call out when the plan does not mathematically fit in the day
 - Point out hidden overload, fragmentation, or unrealistic sequencing
 - Push back on priorities when trade-offs are required
+- Leave buffer in tomorrow's plan in proportion to the unplanned work trend
 
 You should:
 - Look for alignment between weekly goals and Jira tickets
-- Treat calendar events as hard constraints
+- Treat accepted calendar events as hard constraints; tentative or unanswered ones may still be dropped
+- If Calendar Data is STALE, say so before relying on meeting times
 - Treat tasks and tickets as flexible unless stated otherwise
 
 You should NOT:
@@ -107,14 +301,27 @@ Tone and format:
 - If the day appears overcommitted, say so plainly
 
 Inputs:
-Current Weekly Goals: %s
+Current Weekly Goals: %v
 Calendar Events: %v
+Calendar Data: %v
 Jira Tickets: %v
 Current Tasks: %v
+Deferred To Today: %v
+Fires This Week: %v
+Reviews This Week: %v
+Architectural Work This Week: %v
+Follow-ups Due: %v
+Task History (completed/total per section, last 7 days, computed from the notes and accurate):
+%v
+Unplanned Work Trend: %v
+Capacity Today (computed from the calendar and working hours, accurate): %v
+Free Blocks Today: %v
 
 Respond by discussing the plan, highlighting risks or mismatches, or answering the user's question.
 
-`, pContext.WeeklyGoals, pContext.Calendar, pContext.JiraTickets, pContext.CurrentTasks)
+`, pContext.WeeklyGoals, pContext.Calendar, pContext.CalendarFreshness, pContext.JiraTickets, pContext.CurrentTasks, pContext.DeferredTasks,
+		pContext.Annotations.Fires, pContext.Annotations.Reviews, pContext.Annotations.Architecture, pContext.Annotations.FollowUps,
+		pContext.History, pContext.CapacityTrend, pContext.Capacity, pContext.FreeGaps)
 
 	var messages []*ai.Message
 	messages = append(messages, ai.NewSystemMessage(ai.NewTextPart(systemPrompt)))
@@ -140,22 +347,41 @@ Respond by discussing the plan, highlighting risks or mismatches, or answering t
 	return resp.Text(), nil
 }
 
-func (m *ModelInfo) GeneratePlan(ctx context.Context, input PlannerInput) (string, error) {
+// GeneratePlan asks the model for a DayPlan using structured output. When the
+// reply does not parse or fails validation, the model is told what was wrong
+// and asked again, up to planAttempts times.
+func (m *ModelInfo) GeneratePlan(ctx context.Context, input PlannerInput) (*DayPlan, error) {
 	pContext, err := m.fetchContext(ctx)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	systemPrompt := fmt.Sprintf(`
-You are a personal AI planner. Your goal is to help a software engineer plan their day by generating structured updates for their daily note.
-Current Weekly Goals: %s
+You are a personal AI planner. Your goal is to help a software engineer plan their day by proposing the contents of their daily note.
+Current Weekly Goals: %v
 Calendar Events: %v
+Calendar Data: %v
 Jira Tickets: %v
 Current Tasks: %v
-
-Please generate the content for the 'Goals', 'Meetings', and 'Bonus Items' sections. 
-Be specific and professional. Use Markdown format.
-`, pContext.WeeklyGoals, pContext.Calendar, pContext.JiraTickets, pContext.CurrentTasks)
+Deferred To Today: %v
+Fires This Week: %v
+Reviews This Week: %v
+Architectural Work This Week: %v
+Follow-ups Due: %v
+Task History (completed/total per section, last 7 days, computed from the notes and accurate):
+%v
+Unplanned Work Trend: %v
+Capacity Today (computed from the calendar and working hours, accurate): %v
+Free Blocks Today: %v
+
+Reply with JSON only. Plan the 'Goals', 'Meetings', and 'Bonus Items' sections as lists of short items, without Markdown checkboxes or headings.
+Tasks already in the note are always kept; list them to set their order, and explain any change in the rationale.
+Size the Goals to fit the free minutes and focus blocks in Capacity Today.
+Set link to true for meetings that deserve their own note.
+Be specific and professional.
+`, pContext.WeeklyGoals, pContext.Calendar, pContext.CalendarFreshness, pContext.JiraTickets, pContext.CurrentTasks, pContext.DeferredTasks,
+		pContext.Annotations.Fires, pContext.Annotations.Reviews, pContext.Annotations.Architecture, pContext.Annotations.FollowUps,
+		pContext.History, pContext.CapacityTrend, pContext.Capacity, pContext.FreeGaps)
 
 	var messages []*ai.Message
 	messages = append(messages, ai.NewSystemMessage(ai.NewTextPart(systemPrompt)))
@@ -170,15 +396,33 @@ Be specific and professional. Use Markdown format.
 
 	messages = append(messages, ai.NewUserMessage(ai.NewTextPart(input.UserPrompt)))
 
-	resp, err := genkit.Generate(ctx, m.GenKit,
-		ai.WithModel(m.Model),
-		ai.WithMessages(messages...),
-	)
-	if err != nil {
-		return "", err
-	}
+	for attempt := 1; ; attempt++ {
+		plan, resp, err := genkit.GenerateData[DayPlan](ctx, m.GenKit,
+			ai.WithModel(m.Model),
+			ai.WithMessages(messages...),
+		)
+		if err == nil && plan == nil {
+			err = errors.New("the reply was empty")
+		}
+		if err == nil {
+			err = plan.Validate()
+		}
+		if err == nil {
+			return plan, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if attempt == planAttempts {
+			return nil, fmt.Errorf("no valid plan after %d attempts: %w", planAttempts, err)
+		}
 
-	return resp.Text(), nil
+		if resp != nil && resp.Message != nil {
+			messages = append(messages, resp.Message)
+		}
+		messages = append(messages, ai.NewUserMessage(ai.NewTextPart(fmt.Sprintf(
+			"That reply could not be used as a plan: %v. Reply again with only JSON that matches the schema.", err))))
+	}
 }
 
 func (m *ModelInfo) Condense(ctx context.Context, history []Message) (string, error) {
@@ -207,7 +451,7 @@ func (m *ModelInfo) Condense(ctx context.Context, history []Message) (string, er
 }
 
 func DefinePlannerFlow(m *ModelInfo) {
-	genkit.DefineFlow(m.GenKit, "plannerFlow", func(ctx context.Context, input PlannerInput) (string, error) {
+	genkit.DefineFlow(m.GenKit, "plannerFlow", func(ctx context.Context, input PlannerInput) (*DayPlan, error) {
 		return m.GeneratePlan(ctx, input)
 	})
 }
@@ -0,0 +1,132 @@
+package local_ai
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"obsidian-ai-planner/calendar"
+	"obsidian-ai-planner/configuration"
+	"obsidian-ai-planner/secrets"
+)
+
+type fakeCalendar struct {
+	events []calendar.Event
+	start  time.Time
+	end    time.Time
+}
+
+func (f *fakeCalendar) EventsBetween(start, end time.Time) ([]calendar.Event, error) {
+	f.start, f.end = start, end
+	return f.events, nil
+}
+
+func TestFetchContext_Calendar(t *testing.T) {
+	today := time.Now()
+	at := func(hour int) time.Time {
+		return time.Date(today.Year(), today.Month(), today.Day(), hour, 0, 0, 0, time.Local)
+	}
+	cal := &fakeCalendar{events: []calendar.Event{
+		{UID: "review", Name: "Review", Start: at(10), End: at(11), Type: "event"},
+	}}
+	m := &ModelInfo{Calendar: cal, Config: &configuration.Config{}}
+
+	pContext, err := m.fetchContext(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to fetch context: %v", err)
+	}
+	if !cal.end.Equal(cal.start.AddDate(0, 0, 1)) {
+		t.Errorf("Expected a one day query, got %v to %v", cal.start, cal.end)
+	}
+	if len(pContext.Calendar) != 1 {
+		t.Errorf("Expected the fake event, got %v", pContext.Calendar)
+	}
+	if pContext.Capacity == nil || pContext.Capacity.MeetingMinutes != 60 {
+		t.Errorf("Expected 60 meeting minutes, got %v", pContext.Capacity)
+	}
+	// 09:00-10:00 and 11:00-17:00 with the default working hours.
+	if len(pContext.FreeGaps) != 2 || pContext.FreeGaps[1].Kind != calendar.DeepWork {
+		t.Errorf("Expected an admin gap and a deep work gap, got %v", pContext.FreeGaps)
+	}
+	if pContext.CalendarFreshness != "live" {
+		t.Errorf("Expected a live calendar, got %q", pContext.CalendarFreshness)
+	}
+}
+
+func TestCalendarFreshness(t *testing.T) {
+	now := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+	if got := calendarFreshness(now.Add(-2*time.Minute), now); got != "cached, synced 11:58" {
+		t.Errorf("Expected a fresh copy, got %q", got)
+	}
+	got := calendarFreshness(now.Add(-2*time.Hour), now)
+	if !strings.HasPrefix(got, "STALE, last synced Mon 10:00 (2h0m0s ago)") {
+		t.Errorf("Expected a stale copy to be flagged, got %q", got)
+	}
+}
+
+func TestCalendarProviders(t *testing.T) {
+	cfg := &configuration.Config{
+		CalendarUrl: "https://example.com/basic.ics",
+		Calendars: []configuration.CalendarConfig{
+			{Type: "caldav", URL: "https://dav.example.com/alice/work/", Username: "alice"},
+			{Type: "ICS", URL: "holidays.ics"},
+		},
+	}
+	p, err := calendarProviders(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	providers, ok := p.(calendar.Providers)
+	if !ok || len(providers) != 3 {
+		t.Fatalf("Expected 3 providers, got %#v", p)
+	}
+	if _, ok := providers[1].(*calendar.CalDAVCalendar); !ok {
+		t.Errorf("Expected the CalDAV provider second, got %T", providers[1])
+	}
+
+	cfg.Calendars = append(cfg.Calendars, configuration.CalendarConfig{Type: "exchange"})
+	if _, err := calendarProviders(context.Background(), cfg); err == nil {
+		t.Error("Expected error for an unknown calendar type, got nil")
+	}
+}
+
+func TestCalendarProviders_GoogleErrors(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv(secrets.PassphraseEnv, "test passphrase")
+	credentials := filepath.Join(dir, "credentials.json")
+	cfg := &configuration.Config{SecretStore: "file", GoogleCredentials: credentials}
+
+	// Nothing configured and no OAuth client: no calendar, quietly.
+	if p, err := calendarProviders(context.Background(), cfg); p != nil || err != nil {
+		t.Errorf("Expected no calendar and no error, got %v, %v", p, err)
+	}
+
+	// Asked for explicitly, a missing OAuth client is reported.
+	cfg.Calendars = []configuration.CalendarConfig{{Type: "google"}}
+	if _, err := calendarProviders(context.Background(), cfg); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected the missing credentials to be reported, got %v", err)
+	}
+
+	// Not authorized yet: skipped until `auth google` is run.
+	client := `{"installed":{"client_id":"id","client_secret":"secret","redirect_uris":["http://localhost"],"auth_uri":"https://accounts.google.com/o/oauth2/auth","token_uri":"https://oauth2.googleapis.com/token"}}`
+	if err := os.WriteFile(credentials, []byte(client), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+	if p, err := calendarProviders(context.Background(), cfg); p != nil || err != nil {
+		t.Errorf("Expected an unauthorized calendar to be skipped, got %v, %v", p, err)
+	}
+
+	// A corrupt OAuth client is reported.
+	if err := os.WriteFile(credentials, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := calendarProviders(context.Background(), cfg); err == nil {
+		t.Error("Expected a corrupt OAuth client to be reported")
+	}
+}
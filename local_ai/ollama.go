@@ -2,14 +2,22 @@ package local_ai
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"obsidian-ai-planner/calendar"
+	"obsidian-ai-planner/configuration"
+	"obsidian-ai-planner/vault"
+	"os"
+	"strings"
 
 	"github.com/firebase/genkit/go/ai"
 	"github.com/firebase/genkit/go/genkit"
 	"github.com/firebase/genkit/go/plugins/ollama"
 )
 
-func NewOllamaModel(ctx context.Context) (*ModelInfo, error) {
+// NewOllamaModel plans with a local Ollama model, reading the calendars
+// and vault given in cfg.
+func NewOllamaModel(ctx context.Context, cfg *configuration.Config) (*ModelInfo, error) {
 	ollamaPlugin := &ollama.Ollama{
 		ServerAddress: "http://127.0.0.1:11434",
 		Timeout:       60, // Optional field, adjust accordingly
@@ -31,11 +39,114 @@ func NewOllamaModel(ctx context.Context) (*ModelInfo, error) {
 		},
 	)
 
-	cal, _ := calendar.New(ctx)
+	cal, err := calendarProviders(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	v, _ := vault.New(cfg.VaultPath)
+	if v != nil {
+		if dir, err := configuration.HistoryDir(); err == nil {
+			v.Journal = vault.NewJournal(dir)
+		}
+	}
 
 	return &ModelInfo{
 		Model:    model,
 		GenKit:   g,
 		Calendar: cal,
+		Vault:    v,
+		Config:   cfg,
 	}, nil
 }
+
+// calendarProviders builds the configured calendar sources. CalendarUrl is
+// an ICS feed; Google is used when nothing is configured. It returns nil
+// when no source is available. A Google calendar not yet authorized is
+// skipped, as is the implied one when there is no OAuth client; any other
+// failure to open it is returned.
+func calendarProviders(ctx context.Context, cfg *configuration.Config) (calendar.Provider, error) {
+	var providers calendar.Providers
+	emails := cfg.CalendarEmails()
+	feed, err := cfg.FeedURL()
+	if err != nil {
+		return nil, err
+	}
+	if feed != "" {
+		ics := calendar.NewICS(feed)
+		ics.Emails = emails
+		providers = append(providers, ics)
+	}
+	google := len(cfg.Calendars) == 0 && feed == ""
+	implied := google
+	var googleIDs []string
+	for _, c := range cfg.Calendars {
+		switch strings.ToLower(c.Type) {
+		case "ics":
+			source, err := cfg.ICSURL(c)
+			if err != nil {
+				return nil, err
+			}
+			ics := calendar.NewICS(source)
+			ics.Emails = emails
+			providers = append(providers, ics)
+		case "caldav":
+			password, err := cfg.CalDAVPassword(c)
+			if err != nil {
+				return nil, err
+			}
+			dav := calendar.NewCalDAV(c.URL, c.Username, password)
+			dav.Emails = emails
+			providers = append(providers, dav)
+		case "google":
+			google = true
+			googleIDs = append(googleIDs, c.CalendarIDs...)
+		default:
+			return nil, fmt.Errorf("unknown calendar type %q", c.Type)
+		}
+	}
+	if google {
+		g, err := googleProvider(ctx, cfg, googleIDs)
+		switch {
+		case err == nil:
+			providers = append(providers, g)
+		case errors.Is(err, calendar.ErrNotAuthorized), implied && errors.Is(err, os.ErrNotExist):
+		default:
+			return nil, fmt.Errorf("google calendar: %w", err)
+		}
+	}
+	if len(providers) == 0 {
+		return nil, nil
+	}
+	return providers, nil
+}
+
+// googleProvider reads the given Google calendars through the local cache.
+func googleProvider(ctx context.Context, cfg *configuration.Config, ids []string) (calendar.Provider, error) {
+	auth, err := GoogleAuth(cfg)
+	if err != nil {
+		return nil, err
+	}
+	g, err := calendar.New(ctx, auth, ids...)
+	if err != nil {
+		return nil, err
+	}
+	path, err := configuration.CalendarCachePath()
+	if err != nil {
+		return g, nil
+	}
+	return calendar.NewSynced(g, path), nil
+}
+
+// GoogleAuth returns where the Google OAuth client and token are kept.
+func GoogleAuth(cfg *configuration.Config) (calendar.GoogleAuth, error) {
+	credentials, err := cfg.GoogleCredentialsPath()
+	if err != nil {
+		return calendar.GoogleAuth{}, err
+	}
+	store, err := cfg.Secrets()
+	if err != nil {
+		return calendar.GoogleAuth{}, err
+	}
+	return calendar.GoogleAuth{Credentials: credentials, Tokens: calendar.NewSecretTokenStore(store)}, nil
+}
@@ -0,0 +1,118 @@
+package local_ai
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"obsidian-ai-planner/vault"
+)
+
+// planAttempts bounds how often GeneratePlan asks again when the model
+// returns something that is not a valid DayPlan.
+const planAttempts = 3
+
+// DayPlan is the model's proposal for the managed sections of today's note.
+type DayPlan struct {
+	Goals      []string         `json:"goals" jsonschema_description:"Tasks planned for today, one short sentence each, without checkboxes"`
+	Meetings   []PlannedMeeting `json:"meetings" jsonschema_description:"Today's meetings in calendar order"`
+	BonusItems []string         `json:"bonusItems" jsonschema_description:"Unplanned work that came up and is not in the note yet; items already there are kept"`
+	Summary    string           `json:"summary" jsonschema_description:"Two or three sentences on the shape of the day"`
+	Rationale  string           `json:"rationale" jsonschema_description:"Why the plan changes what is already in the note"`
+}
+
+// PlannedMeeting is a meeting entry. Link asks for a wiki link to a meeting
+// note, which is worth it for meetings that need notes but not for standups.
+type PlannedMeeting struct {
+	Title string `json:"title"`
+	Link  bool   `json:"link" jsonschema_description:"Whether the meeting gets its own note"`
+}
+
+// Validate reports the first problem that would stop the plan being written
+// to the note as list items.
+func (p *DayPlan) Validate() error {
+	if strings.TrimSpace(p.Summary) == "" {
+		return errors.New("summary is empty")
+	}
+	check := func(field string, items []string) error {
+		for i, item := range items {
+			if err := validateItem(item); err != nil {
+				return fmt.Errorf("%s[%d]: %w", field, i, err)
+			}
+		}
+		return nil
+	}
+	if err := check("goals", p.Goals); err != nil {
+		return err
+	}
+	if err := check("bonusItems", p.BonusItems); err != nil {
+		return err
+	}
+	for i, m := range p.Meetings {
+		if err := validateItem(m.Title); err != nil {
+			return fmt.Errorf("meetings[%d]: %w", i, err)
+		}
+		if strings.ContainsAny(m.Title, "[]|#^") {
+			return fmt.Errorf("meetings[%d]: title %q cannot be used as a note name", i, m.Title)
+		}
+	}
+	return nil
+}
+
+func validateItem(item string) error {
+	switch {
+	case strings.TrimSpace(item) == "":
+		return errors.New("item is empty")
+	case strings.ContainsAny(item, "\r\n"):
+		return fmt.Errorf("item %q spans several lines", item)
+	case strings.HasPrefix(strings.TrimSpace(item), "#"):
+		return fmt.Errorf("item %q is a heading", item)
+	}
+	return nil
+}
+
+// Updates merges the plan into the managed sections of note, which is the
+// note of date. Nothing already in the note is removed: its tasks keep their
+// status, inline fields and subtasks, so re-planning does not reopen work
+// that is done, and the plan only adds tasks and orders those it lists.
+func (p *DayPlan) Updates(date time.Time, note *vault.DailyNote) vault.SectionUpdates {
+	merge := func(section vault.Section, items []string) *string {
+		texts := make([]string, len(items))
+		for i, item := range items {
+			texts[i] = planItem(item)
+		}
+		body := vault.MergeTasks(note.Section(section), texts)
+		return &body
+	}
+
+	meetings := make([]string, len(p.Meetings))
+	for i, m := range p.Meetings {
+		meetings[i] = m.Markdown(date)
+	}
+	return vault.SectionUpdates{
+		Goals:      merge(vault.SectionGoals, p.Goals),
+		Meetings:   merge(vault.SectionMeetings, meetings),
+		BonusItems: merge(vault.SectionBonusItems, p.BonusItems),
+	}
+}
+
+// planItem strips the bullet and checkbox a model sometimes echoes back,
+// such as "- [x] ", leaving the task text.
+func planItem(item string) string {
+	text := strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(item), "-*+ "))
+	if r := []rune(text); len(r) >= 3 && r[0] == '[' && r[2] == ']' {
+		text = strings.TrimSpace(string(r[3:]))
+	}
+	return text
+}
+
+// Markdown returns the meeting as it is written in the note, linking to
+// YYYY/MM/<title> like the existing meeting notes.
+func (m PlannedMeeting) Markdown(date time.Time) string {
+	title := strings.TrimSpace(m.Title)
+	if !m.Link {
+		return title
+	}
+	return fmt.Sprintf("[[%s/%s|%s]]", date.Format("2006/01"), title, title)
+}
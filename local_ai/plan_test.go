@@ -0,0 +1,81 @@
+package local_ai
+
+import (
+	"testing"
+	"time"
+
+	"obsidian-ai-planner/vault"
+)
+
+func TestDayPlan_Validate(t *testing.T) {
+	valid := DayPlan{
+		Goals:    []string{"Ship the vault adapter"},
+		Meetings: []PlannedMeeting{{Title: "Roadmap Review", Link: true}},
+		Summary:  "A focused day.",
+	}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Expected valid plan, got %v", err)
+	}
+
+	tests := map[string]DayPlan{
+		"no summary":      {Goals: []string{"Ship it"}},
+		"empty goal":      {Goals: []string{" "}, Summary: "s"},
+		"multi-line goal": {Goals: []string{"one\ntwo"}, Summary: "s"},
+		"heading":         {BonusItems: []string{"## Notes"}, Summary: "s"},
+		"bad link title":  {Meetings: []PlannedMeeting{{Title: "a|b", Link: true}}, Summary: "s"},
+	}
+	for name, plan := range tests {
+		if err := plan.Validate(); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+}
+
+func TestDayPlan_Updates(t *testing.T) {
+	plan := DayPlan{
+		Goals:    []string{"- [ ] Write the section editor", "- [x] Ship the vault adapter"},
+		Meetings: []PlannedMeeting{{Title: "Standup"}, {Title: "Roadmap Review", Link: true}},
+		Summary:  "s",
+	}
+	note, err := vault.ParseDailyNote([]byte("# Goals\n" +
+		"- [x] Ship the vault adapter\n" +
+		"    - [x] Parse sections [review:: yes]\n\n" +
+		"# Meetings\n- [x] Standup\n\n" +
+		"# Bonus Items\n- [ ] Fix the flaky test\n"))
+	if err != nil {
+		t.Fatalf("Failed to parse note: %v", err)
+	}
+
+	updates := plan.Updates(time.Date(2024, 1, 10, 0, 0, 0, 0, time.Local), note)
+	want := "- [ ] Write the section editor\n- [x] Ship the vault adapter\n    - [x] Parse sections [review:: yes]"
+	if updates.Goals == nil || *updates.Goals != want {
+		t.Errorf("Unexpected Goals: %v", updates.Goals)
+	}
+	if updates.Meetings == nil || *updates.Meetings != "- [x] Standup\n- [ ] [[2024/01/Roadmap Review|Roadmap Review]]" {
+		t.Errorf("Unexpected Meetings: %v", updates.Meetings)
+	}
+	if updates.BonusItems == nil || *updates.BonusItems != "- [ ] Fix the flaky test" {
+		t.Errorf("Expected Bonus Items to be kept, got %v", updates.BonusItems)
+	}
+}
+
+func TestDayPlan_UpdatesKeepsOmittedTasks(t *testing.T) {
+	plan := DayPlan{Goals: []string{"Write the section editor"}, Summary: "s"}
+	note, err := vault.ParseDailyNote([]byte("# Goals\n" +
+		"- [x] Ship the vault adapter\n" +
+		"- [-] Drop the old parser\n\n" +
+		"# Meetings\n\n" +
+		"# Bonus Items\n- [ ] Answer the security questionnaire\n"))
+	if err != nil {
+		t.Fatalf("Failed to parse note: %v", err)
+	}
+
+	updates := plan.Updates(time.Date(2024, 1, 10, 0, 0, 0, 0, time.Local), note)
+	want := "- [x] Ship the vault adapter\n- [-] Drop the old parser\n- [ ] Write the section editor"
+	if updates.Goals == nil || *updates.Goals != want {
+		t.Errorf("Expected the done and cancelled goals to survive, got %v", updates.Goals)
+	}
+	if updates.BonusItems == nil || *updates.BonusItems != "- [ ] Answer the security questionnaire" {
+		t.Errorf("Expected the hand-written bonus item to survive, got %v", updates.BonusItems)
+	}
+}
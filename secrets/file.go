@@ -0,0 +1,196 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"filippo.io/age"
+	"golang.org/x/term"
+)
+
+// PassphraseEnv holds the passphrase of the encrypted file, for when there
+// is no terminal to ask on.
+const PassphraseEnv = "PLANNER_PASSPHRASE"
+
+// File stores secrets as JSON encrypted with age under a passphrase. The
+// passphrase is asked for once and the contents kept in memory after.
+type File struct {
+	Path       string
+	Passphrase func() (string, error)
+
+	// workFactor overrides age's scrypt work factor; tests lower it.
+	workFactor int
+
+	mu         sync.Mutex
+	passphrase string
+	secrets    map[string]string
+}
+
+// NewFile returns the store in the file at path, asking passphrase for the
+// key.
+func NewFile(path string, passphrase func() (string, error)) *File {
+	return &File{Path: path, Passphrase: passphrase}
+}
+
+func (f *File) Get(name string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.loadLocked(); err != nil {
+		return "", err
+	}
+	value, ok := f.secrets[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+func (f *File) Set(name, value string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.loadLocked(); err != nil {
+		return err
+	}
+	f.secrets[name] = value
+	return f.saveLocked()
+}
+
+func (f *File) Delete(name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.loadLocked(); err != nil {
+		return err
+	}
+	if _, ok := f.secrets[name]; !ok {
+		return nil
+	}
+	delete(f.secrets, name)
+	return f.saveLocked()
+}
+
+// Unlock asks for the passphrase now, and checks it against the file when
+// there is one.
+func (f *File) Unlock() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.loadLocked(); err != nil {
+		return err
+	}
+	return f.askLocked()
+}
+
+// loadLocked decrypts the file the first time it is needed. A missing file
+// is an empty store.
+func (f *File) loadLocked() error {
+	if f.secrets != nil {
+		return nil
+	}
+	data, err := os.ReadFile(f.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		f.secrets = make(map[string]string)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := f.askLocked(); err != nil {
+		return err
+	}
+	identity, err := age.NewScryptIdentity(f.passphrase)
+	if err != nil {
+		return err
+	}
+	r, err := age.Decrypt(bytes.NewReader(data), identity)
+	if err != nil {
+		f.passphrase = ""
+		return fmt.Errorf("decrypting %s: %w", f.Path, err)
+	}
+	plain, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("decrypting %s: %w", f.Path, err)
+	}
+	secrets := make(map[string]string)
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return fmt.Errorf("reading %s: %w", f.Path, err)
+	}
+	f.secrets = secrets
+	return nil
+}
+
+func (f *File) saveLocked() error {
+	if err := f.askLocked(); err != nil {
+		return err
+	}
+	recipient, err := age.NewScryptRecipient(f.passphrase)
+	if err != nil {
+		return err
+	}
+	if f.workFactor > 0 {
+		recipient.SetWorkFactor(f.workFactor)
+	}
+	plain, err := json.Marshal(f.secrets)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, recipient)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(plain); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0700); err != nil {
+		return err
+	}
+	// Write beside the file and rename, so a failed write cannot lose the
+	// secrets already stored.
+	tmp := f.Path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.Path)
+}
+
+func (f *File) askLocked() error {
+	if f.passphrase != "" {
+		return nil
+	}
+	if f.Passphrase == nil {
+		return errors.New("no passphrase for the secrets file")
+	}
+	passphrase, err := f.Passphrase()
+	if err != nil {
+		return err
+	}
+	if passphrase == "" {
+		return errors.New("empty passphrase for the secrets file")
+	}
+	f.passphrase = passphrase
+	return nil
+}
+
+// Passphrase reads the passphrase from $PLANNER_PASSPHRASE, or asks for it
+// on the terminal.
+func Passphrase() (string, error) {
+	if p := os.Getenv(PassphraseEnv); p != "" {
+		return p, nil
+	}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("no terminal to ask for the secrets passphrase, set %s", PassphraseEnv)
+	}
+	fmt.Fprint(os.Stderr, "Passphrase for the planner's secrets: ")
+	p, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	return string(p), err
+}
@@ -0,0 +1,91 @@
+package secrets
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func fixedPassphrase(p string) func() (string, error) {
+	return func() (string, error) { return p, nil }
+}
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "planner", "secrets.age")
+	f := NewFile(path, fixedPassphrase("correct horse"))
+	f.workFactor = 10
+	if _, err := f.Get("jira-token"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound from an empty store, got %v", err)
+	}
+	if err := f.Set("jira-token", "s3cret-value"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Set("google-token", `{"access_token":"a"}`); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Delete("google-token"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("s3cret-value")) {
+		t.Error("Expected the secret to be encrypted on disk")
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("Expected 0600, got %v", info.Mode().Perm())
+	}
+
+	reopened := NewFile(path, fixedPassphrase("correct horse"))
+	if got, err := reopened.Get("jira-token"); err != nil || got != "s3cret-value" {
+		t.Errorf("Expected the saved secret, got %q, %v", got, err)
+	}
+	if _, err := reopened.Get("google-token"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected the deleted secret to be gone, got %v", err)
+	}
+
+	wrong := NewFile(path, fixedPassphrase("battery staple"))
+	if _, err := wrong.Get("jira-token"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected a wrong passphrase to fail, got %v", err)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	if _, err := Open("vault", "secrets.age"); err == nil {
+		t.Error("Expected an unknown store to be rejected")
+	}
+	s, err := Open("file", "secrets.age")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f, ok := s.(*File); !ok || f.Path != "secrets.age" {
+		t.Errorf("Expected the encrypted file, got %#v", s)
+	}
+}
+
+func TestFile_Unlock(t *testing.T) {
+	asked := 0
+	f := NewFile(filepath.Join(t.TempDir(), "secrets.age"), func() (string, error) {
+		asked++
+		return "correct horse", nil
+	})
+	f.workFactor = 10
+	if err := f.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Set("jira-token", "s3cret"); err != nil {
+		t.Fatal(err)
+	}
+	if asked != 1 {
+		t.Errorf("Expected the passphrase to be asked for once, on Unlock, got %d", asked)
+	}
+
+	wrong := NewFile(f.Path, fixedPassphrase("battery staple"))
+	if err := wrong.Unlock(); err == nil {
+		t.Error("Expected Unlock to check the passphrase against the file")
+	}
+}
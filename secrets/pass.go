@@ -0,0 +1,59 @@
+package secrets
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Pass stores secrets with pass, the standard Unix password manager, under
+// obsidian-ai-planner/ in the password store. pass asks gpg-agent for the
+// key, so there is no passphrase to handle here.
+type Pass struct{}
+
+func (Pass) Get(name string) (string, error) {
+	out, err := pass(nil, "show", passName(name))
+	if err != nil {
+		if strings.Contains(err.Error(), "is not in the password store") {
+			return "", ErrNotFound
+		}
+		return "", err
+	}
+	return strings.TrimSuffix(out, "\n"), nil
+}
+
+func (Pass) Set(name, value string) error {
+	_, err := pass(strings.NewReader(value+"\n"), "insert", "--multiline", "--force", passName(name))
+	return err
+}
+
+func (Pass) Delete(name string) error {
+	_, err := pass(nil, "rm", "--force", passName(name))
+	if err != nil && strings.Contains(err.Error(), "is not in the password store") {
+		return nil
+	}
+	return err
+}
+
+func passName(name string) string {
+	return Service + "/" + name
+}
+
+// pass runs the pass command, returning its output or an error carrying
+// what it printed on stderr.
+func pass(stdin *strings.Reader, args ...string) (string, error) {
+	cmd := exec.Command("pass", args...)
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("pass %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("pass %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,50 @@
+package secrets
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakePass is a pass stand-in keeping entries as plain files in $STORE.
+const fakePass = `#!/bin/sh
+cmd=$1; shift
+while [ "${1#--}" != "$1" ]; do shift; done
+file="$STORE/$(echo "$1" | tr / _)"
+case $cmd in
+show) [ -f "$file" ] || { echo "Error: $1 is not in the password store." >&2; exit 1; }; cat "$file" ;;
+insert) cat > "$file" ;;
+rm) [ -f "$file" ] || { echo "Error: $1 is not in the password store." >&2; exit 1; }; rm "$file" ;;
+esac
+`
+
+func TestPass(t *testing.T) {
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "pass"), []byte(fakePass), 0755); err != nil {
+		t.Fatal(err)
+	}
+	store := t.TempDir()
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("STORE", store)
+
+	p := Pass{}
+	if _, err := p.Get("jira-token"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if err := p.Set("jira-token", "s3cret"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(store, "obsidian-ai-planner_jira-token")); err != nil {
+		t.Errorf("Expected the entry under obsidian-ai-planner/, got %v", err)
+	}
+	if got, err := p.Get("jira-token"); err != nil || got != "s3cret" {
+		t.Errorf("Expected s3cret, got %q, %v", got, err)
+	}
+	if err := p.Delete("jira-token"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Delete("jira-token"); err != nil {
+		t.Errorf("Expected deleting a missing entry to succeed, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+// Package secrets keeps tokens and passwords out of the config file, in the
+// OS keyring, in pass or in a passphrase-encrypted file.
+package secrets
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/zalando/go-keyring"
+)
+
+// ErrNotFound means no secret is stored under the name.
+var ErrNotFound = errors.New("secret not found")
+
+// Service names the planner's entries in the keyring.
+const Service = "obsidian-ai-planner"
+
+// Store holds named secrets.
+type Store interface {
+	// Get returns the secret, or ErrNotFound.
+	Get(name string) (string, error)
+	Set(name, value string) error
+	// Delete removes the secret; deleting a missing one is not an error.
+	Delete(name string) error
+}
+
+// Unlocker is implemented by stores that ask for a passphrase, so it can be
+// asked for before a full-screen program takes over the terminal.
+type Unlocker interface {
+	Unlock() error
+}
+
+// Keyring stores secrets in the OS keyring: the Secret Service over D-Bus
+// on Linux, the Keychain on macOS and the Credential Manager on Windows.
+type Keyring struct{}
+
+func (Keyring) Get(name string) (string, error) {
+	value, err := keyring.Get(Service, name)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", ErrNotFound
+	}
+	return value, err
+}
+
+func (Keyring) Set(name, value string) error {
+	return keyring.Set(Service, name, value)
+}
+
+func (Keyring) Delete(name string) error {
+	err := keyring.Delete(Service, name)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// KeyringAvailable reports whether the OS keyring can be reached, which it
+// cannot on a headless Linux machine without a Secret Service.
+func KeyringAvailable() bool {
+	_, err := Keyring{}.Get("probe")
+	return err == nil || errors.Is(err, ErrNotFound)
+}
+
+// DefaultKind is the store to use when none is configured: the keyring
+// when it is available, the encrypted file otherwise. The choice depends
+// on the session, so it should be saved once made.
+func DefaultKind() string {
+	if KeyringAvailable() {
+		return "keyring"
+	}
+	return "file"
+}
+
+// Open returns the store of the given kind: "keyring", "pass", "file" for
+// the encrypted file at path, or "" for DefaultKind.
+func Open(kind, path string) (Store, error) {
+	if kind == "" {
+		kind = DefaultKind()
+	}
+	switch kind {
+	case "keyring":
+		return Keyring{}, nil
+	case "pass":
+		return Pass{}, nil
+	case "file":
+		return NewFile(path, Passphrase), nil
+	}
+	return nil, fmt.Errorf("unknown secret store %q, use keyring, pass or file", kind)
+}
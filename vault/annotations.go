@@ -0,0 +1,158 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FieldItem is a task or note that the weekly template picks out by one of
+// its fields.
+type FieldItem struct {
+	Text  string `json:"text"`
+	Value string `json:"value,omitempty"`
+	Note  string `json:"note"`
+	Done  bool   `json:"done"`
+}
+
+func (f FieldItem) String() string {
+	var b strings.Builder
+	b.WriteString(f.Text)
+	if f.Value != "" && f.Value != f.Text {
+		fmt.Fprintf(&b, " (%s)", f.Value)
+	}
+	fmt.Fprintf(&b, " in %s", f.Note)
+	if f.Done {
+		b.WriteString(", done")
+	}
+	return b.String()
+}
+
+// Annotations are the fires, reviews, architectural work and follow-ups of
+// a week, the same items the weekly template's Fires, Reviews and
+// Architectural Work sections list.
+type Annotations struct {
+	Fires        []FieldItem `json:"fires,omitempty"`
+	Reviews      []FieldItem `json:"reviews,omitempty"`
+	Architecture []FieldItem `json:"architecture,omitempty"`
+	FollowUps    []FieldItem `json:"followUps,omitempty"`
+}
+
+// templatesFolder is the folder the weekly template's queries leave out.
+const templatesFolder = "templates"
+
+// WeekAnnotations collects the annotations for the week covering date. Tasks
+// come from the week's daily notes: fire:: fields and "f" tasks are fires,
+// review:: fields are reviews. Notes created during the week, by Dataview's
+// file.ctime, are fires when they have a fire field and architectural work
+// when they have an architect field or are not daily notes and have
+// role: architect. Notes tagged #followup with role: architect and a
+// followup-date up to the end of the week are follow-ups.
+func (v *Vault) WeekAnnotations(date time.Time) (Annotations, error) {
+	var a Annotations
+	start, end, err := v.weekRange(date)
+	if err != nil {
+		return a, err
+	}
+
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		path, err := v.DailyNotePath(day)
+		if err != nil {
+			return a, err
+		}
+		content, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return a, err
+		}
+		for _, t := range flattenTasks(ParseTasks(content)) {
+			item := FieldItem{Text: StripInlineFields(t.Text), Note: v.link(path), Done: t.Status.Completed()}
+			if fire, ok := t.Fields.Get("fire"); ok || t.Status == StatusFire {
+				item.Value = fire
+				a.Fires = append(a.Fires, item)
+			}
+			if review, ok := t.Fields.Get("review"); ok {
+				item.Value = review
+				a.Reviews = append(a.Reviews, item)
+			}
+		}
+	}
+
+	err = v.walkNotes(func(path string) error {
+		if rel, err := filepath.Rel(v.Root, path); err == nil && filepath.Dir(rel) == templatesFolder {
+			return nil
+		}
+		note, err := ReadNote(path)
+		if err != nil {
+			// A note with broken frontmatter is not ours to fix, keep looking.
+			return nil
+		}
+		fields := note.Fields()
+		name := strings.TrimSuffix(filepath.Base(path), ".md")
+		role, _ := fields.Get("role")
+		architect := strings.TrimSpace(role) == "architect"
+		if followUp, ok := fields.Get("followup-date"); ok && architect && note.HasTag("followup") {
+			if due, ok := parseDate(followUp); ok && !due.After(end) {
+				status, _ := fields.Get("status")
+				a.FollowUps = append(a.FollowUps, FieldItem{
+					Text:  name,
+					Value: followUp,
+					Note:  v.link(path),
+					Done:  isClosedStatus(status),
+				})
+			}
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil
+		}
+		created := createdAt(path, info)
+		if created.Before(start) || !created.Before(end.AddDate(0, 0, 1)) {
+			return nil
+		}
+		if fire, ok := fields.Get("fire"); ok {
+			a.Fires = append(a.Fires, FieldItem{Text: name, Value: fire, Note: v.link(path)})
+		}
+		if comment, ok := fields.Get("architect"); ok {
+			a.Architecture = append(a.Architecture, FieldItem{Text: name, Value: comment, Note: v.link(path)})
+		} else if architect && !note.HasTag("daily-log") {
+			topics, _ := fields.Get("topics")
+			a.Architecture = append(a.Architecture, FieldItem{Text: name, Value: topics, Note: v.link(path)})
+		}
+		return nil
+	})
+	sort.SliceStable(a.FollowUps, func(i, j int) bool { return a.FollowUps[i].Value < a.FollowUps[j].Value })
+	return a, err
+}
+
+// weekRange returns the first and last day of the week covering date: the
+// weekly note's journal dates when there is one, Monday to Sunday otherwise.
+func (v *Vault) weekRange(date time.Time) (time.Time, time.Time, error) {
+	note, err := v.WeeklyNote(date)
+	if err == nil {
+		start, _ := note.Date("journal-date")
+		end, _ := note.Date("journal-end-date")
+		return start, end, nil
+	}
+	if !errors.Is(err, ErrNoteNotFound) {
+		return time.Time{}, time.Time{}, err
+	}
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local)
+	start := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	return start, start.AddDate(0, 0, 6), nil
+}
+
+func isClosedStatus(status string) bool {
+	switch strings.ToLower(strings.TrimSpace(status)) {
+	case "done", "closed", "complete", "completed", "resolved":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package vault
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// CapacityTrendHeading is the weekly note heading the capacity trend is
+// written under.
+const CapacityTrendHeading = "## Capacity Trend"
+
+func blockMarkers(name string) (string, string) {
+	return "<!-- planner:" + name + " -->", "<!-- /planner:" + name + " -->"
+}
+
+// UpdateBlock replaces the body of the planner-managed block called name in
+// the note at path. The block lives between HTML comment markers, which
+// Obsidian does not render, so it can sit in notes whose template has no
+// section for the planner. When the markers are missing, heading and the
+// block are appended to the note.
+func (v *Vault) UpdateBlock(path, name, heading, body string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	// Keep the note's line endings, so an unchanged block compares equal.
+	nl := "\n"
+	if bytes.Contains(content, []byte("\r\n")) {
+		nl = "\r\n"
+	}
+	start, end := blockMarkers(name)
+	body = strings.ReplaceAll(strings.ReplaceAll(strings.TrimSpace(body), "\r\n", "\n"), "\n", nl)
+	block := start + nl + body + nl + end
+
+	var updated []byte
+	open := bytes.Index(content, []byte(start))
+	closing := bytes.Index(content, []byte(end))
+	switch {
+	case open >= 0 && closing > open:
+		updated = concat(content[:open], []byte(block), content[closing+len(end):])
+	case open >= 0 || closing >= 0:
+		return fmt.Errorf("%s: the planner:%s block is missing one of its markers", path, name)
+	default:
+		updated = append([]byte{}, bytes.TrimRight(content, "\r\n\t ")...)
+		if len(updated) > 0 {
+			updated = append(updated, nl+nl...)
+		}
+		updated = append(updated, heading+nl+block+nl...)
+	}
+	if bytes.Equal(updated, content) {
+		return nil
+	}
+	return v.writeNote(path, updated)
+}
+
+// UpdateCapacityTrend writes body into the capacity trend block of the
+// weekly note covering date.
+func (v *Vault) UpdateCapacityTrend(date time.Time, body string) error {
+	note, err := v.WeeklyNote(date)
+	if err != nil {
+		return err
+	}
+	return v.UpdateBlock(note.Path, "capacity", CapacityTrendHeading, body)
+}
@@ -0,0 +1,89 @@
+package vault
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUpdateBlock(t *testing.T) {
+	v := writeFiles(t, map[string]string{"note.md": "# Week\n\nSome notes.\n"})
+	path := filepath.Join(v.Root, "note.md")
+
+	if err := v.UpdateBlock(path, "capacity", CapacityTrendHeading, "first"); err != nil {
+		t.Fatalf("Failed to append block: %v", err)
+	}
+	want := "# Week\n\nSome notes.\n\n## Capacity Trend\n<!-- planner:capacity -->\nfirst\n<!-- /planner:capacity -->\n"
+	if got := readFile(t, path); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	if err := v.UpdateBlock(path, "capacity", CapacityTrendHeading, "second"); err != nil {
+		t.Fatalf("Failed to replace block: %v", err)
+	}
+	got := readFile(t, path)
+	if strings.Count(got, CapacityTrendHeading) != 1 {
+		t.Errorf("Expected the heading once, got %q", got)
+	}
+	if !strings.Contains(got, "<!-- planner:capacity -->\nsecond\n<!-- /planner:capacity -->") || strings.Contains(got, "first") {
+		t.Errorf("Expected the block body to be replaced, got %q", got)
+	}
+}
+
+func TestUpdateBlock_Unchanged(t *testing.T) {
+	v := writeFiles(t, map[string]string{"note.md": "<!-- planner:capacity -->\nsame\n<!-- /planner:capacity -->\n"})
+	v.Journal = NewJournal(t.TempDir())
+	path := filepath.Join(v.Root, "note.md")
+
+	if err := v.UpdateBlock(path, "capacity", CapacityTrendHeading, "same"); err != nil {
+		t.Fatalf("Failed to update block: %v", err)
+	}
+	entries, err := v.Journal.Entries()
+	if err != nil {
+		t.Fatalf("Failed to read journal: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected no write for an unchanged block, got %d journal entries", len(entries))
+	}
+}
+
+func TestUpdateBlock_UnchangedCRLF(t *testing.T) {
+	v := writeFiles(t, map[string]string{"note.md": "# Week\r\n"})
+	v.Journal = NewJournal(t.TempDir())
+	path := filepath.Join(v.Root, "note.md")
+
+	for range 2 {
+		if err := v.UpdateBlock(path, "capacity", CapacityTrendHeading, "line one\nline two"); err != nil {
+			t.Fatalf("Failed to update block: %v", err)
+		}
+	}
+	if got := readFile(t, path); strings.Contains(strings.ReplaceAll(got, "\r\n", ""), "\n") {
+		t.Errorf("Expected CRLF line endings throughout, got %q", got)
+	}
+	entries, err := v.Journal.Entries()
+	if err != nil {
+		t.Fatalf("Failed to read journal: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected one write, got %d journal entries", len(entries))
+	}
+}
+
+func TestUpdateBlock_MissingMarker(t *testing.T) {
+	v := writeFiles(t, map[string]string{"note.md": "<!-- planner:capacity -->\nleft open\n"})
+	path := filepath.Join(v.Root, "note.md")
+
+	if err := v.UpdateBlock(path, "capacity", CapacityTrendHeading, "body"); err == nil {
+		t.Error("Expected error for a block missing its end marker, got nil")
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	return string(content)
+}
@@ -0,0 +1,242 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// carryoverLookback bounds how many days back FindCarryover searches for the
+// last daily note, so a long holiday does not send it through the archive.
+const carryoverLookback = 14
+
+// CarryoverAction is what the user chose to do with an incomplete task.
+type CarryoverAction int
+
+const (
+	CarryForward CarryoverAction = iota
+	Drop
+	Defer
+)
+
+func (a CarryoverAction) String() string {
+	switch a {
+	case CarryForward:
+		return "carry"
+	case Drop:
+		return "drop"
+	case Defer:
+		return "defer"
+	}
+	return "unknown"
+}
+
+// CarryoverDecision pairs a task with the user's choice. Until is the day a
+// deferred task is queued for; a deferral without one leaves the task as is.
+type CarryoverDecision struct {
+	Task   *Task
+	Action CarryoverAction
+	Until  time.Time
+}
+
+// PreviousBusinessDay returns the closest day before date that is neither a
+// weekend nor one of holidays.
+func PreviousBusinessDay(date time.Time, holidays []time.Time) time.Time {
+	return businessDay(date, -1, holidays)
+}
+
+// NextBusinessDay returns the closest day after date that is neither a
+// weekend nor one of holidays.
+func NextBusinessDay(date time.Time, holidays []time.Time) time.Time {
+	return businessDay(date, 1, holidays)
+}
+
+func businessDay(date time.Time, step int, holidays []time.Time) time.Time {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	for {
+		day = day.AddDate(0, 0, step)
+		if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			continue
+		}
+		if isHoliday(day, holidays) {
+			continue
+		}
+		return day
+	}
+}
+
+func isHoliday(day time.Time, holidays []time.Time) bool {
+	for _, h := range holidays {
+		if h.Year() == day.Year() && h.Month() == day.Month() && h.Day() == day.Day() {
+			return true
+		}
+	}
+	return false
+}
+
+// FindCarryover finds the daily note of the most recent business day before
+// date and returns its path with the incomplete leaf tasks under Goals and
+// Bonus Items. Meetings are not carried; they come from the calendar. An
+// empty path means no earlier note was found.
+func (v *Vault) FindCarryover(date time.Time, holidays []time.Time) (string, []*Task, error) {
+	limit := date.AddDate(0, 0, -carryoverLookback)
+	for day := PreviousBusinessDay(date, holidays); day.After(limit); day = PreviousBusinessDay(day, holidays) {
+		path, err := v.DailyNotePath(day)
+		if err != nil {
+			return "", nil, err
+		}
+		note, err := v.ReadDailyNote(path)
+		if errors.Is(err, os.ErrNotExist) || errors.Is(err, ErrSectionNotFound) {
+			continue
+		}
+		if err != nil {
+			return "", nil, err
+		}
+
+		var tasks []*Task
+		for _, t := range flattenTasks(note.Tasks()) {
+			if t.Section == string(SectionMeetings) || !t.Leaf() || !t.Status.Open() {
+				continue
+			}
+			tasks = append(tasks, t)
+		}
+		return path, tasks, nil
+	}
+	return "", nil, nil
+}
+
+// ApplyCarryover adds carried tasks to the Goals of the note at todayPath,
+// queues deferred ones and updates the statuses in the note they came from:
+// carried and deferred tasks become ">" so the weekly Moved column counts
+// them, and dropped tasks become "-".
+func (v *Vault) ApplyCarryover(fromPath, todayPath string, decisions []CarryoverDecision) error {
+	// Check every source task before writing anything.
+	content, err := os.ReadFile(fromPath)
+	if err != nil {
+		return err
+	}
+	var carried []*Task
+	var queued []string
+	changed := false
+	for _, d := range decisions {
+		switch d.Action {
+		case CarryForward:
+			content, err = setTaskStatus(content, d.Task, StatusMoved)
+			carried = append(carried, d.Task)
+		case Drop:
+			content, err = setTaskStatus(content, d.Task, StatusCancelled)
+		case Defer:
+			if d.Until.IsZero() {
+				continue
+			}
+			var entry string
+			content, entry, err = v.deferTask(content, fromPath, d.Task, d.Until)
+			queued = append(queued, entry)
+		default:
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+
+	// Prepare the other notes too, so a failure leaves every file as it
+	// was, then write the source note first: should a later write fail,
+	// tasks are at worst marked moved, never listed twice.
+	var goals []byte
+	if len(carried) > 0 {
+		if goals, err = v.addGoals(todayPath, carried); err != nil {
+			return err
+		}
+	}
+	deferredPath := filepath.Join(v.Root, DeferredNote)
+	var deferred []byte
+	if len(queued) > 0 {
+		if deferred, err = v.enqueueDeferred(deferredPath, queued); err != nil {
+			return err
+		}
+	}
+	writes := []noteWrite{{fromPath, content}}
+	if goals != nil {
+		writes = append(writes, noteWrite{todayPath, goals})
+	}
+	if deferred != nil {
+		writes = append(writes, noteWrite{deferredPath, deferred})
+	}
+	return v.writeNotes(writes...)
+}
+
+// addGoals returns the note at path with tasks appended to its Goals section
+// as open tasks, skipping any that are already listed there. It returns nil
+// when there is nothing to add.
+func (v *Vault) addGoals(path string, tasks []*Task) ([]byte, error) {
+	note, err := v.ReadDailyNote(path)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]bool)
+	for _, t := range flattenTasks(note.Tasks()) {
+		if t.Section == string(SectionGoals) {
+			existing[t.Text] = true
+		}
+	}
+
+	goals := strings.TrimRight(note.Section(SectionGoals), "\r\n")
+	added := false
+	for _, t := range tasks {
+		if existing[t.Text] {
+			continue
+		}
+		existing[t.Text] = true
+		added = true
+		if strings.TrimSpace(goals) != "" {
+			goals += "\n"
+		}
+		goals += "- [ ] " + t.Text
+	}
+	if !added {
+		return nil, nil
+	}
+	if err := note.Apply(SectionUpdates{Goals: &goals}); err != nil {
+		return nil, err
+	}
+	return note.Bytes(), nil
+}
+
+// setTaskStatus rewrites the checkbox of task in content. It refuses to touch
+// the line if it no longer holds the task as it was read, which happens when
+// the note was edited in the meantime.
+func setTaskStatus(content []byte, task *Task, status TaskStatus) ([]byte, error) {
+	return updateTaskLine(content, task, status, "")
+}
+
+// updateTaskLine sets the checkbox of task and appends suffix to its text,
+// with the same safety check as setTaskStatus.
+func updateTaskLine(content []byte, task *Task, status TaskStatus, suffix string) ([]byte, error) {
+	offset := 0
+	for i := 0; i < task.Line && offset < len(content); i++ {
+		_, offset = nextLine(content, offset)
+	}
+	line, _ := nextLine(content, offset)
+	current, text, ok := parseTaskLine(line)
+	if !ok || text != task.Text {
+		return nil, fmt.Errorf("task %q is no longer on line %d", task.Text, task.Line+1)
+	}
+	if TaskStatus(current) != task.Status {
+		return nil, fmt.Errorf("task %q was changed to [%c] since it was read", task.Text, current)
+	}
+	open := offset + strings.IndexByte(line, '[')
+	closing := offset + strings.IndexByte(line, ']')
+	end := offset + len(strings.TrimRight(strings.TrimRight(line, "\r"), " \t"))
+	updated := concat(content[:open+1], []byte(status.String()), content[closing:end], []byte(suffix), content[end:])
+	task.Status = status
+	task.Text += suffix
+	return updated, nil
+}
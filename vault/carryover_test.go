@@ -0,0 +1,196 @@
+package vault
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPreviousBusinessDay(t *testing.T) {
+	holidays := []time.Time{time.Date(2024, 1, 15, 0, 0, 0, 0, time.Local)}
+	tests := []struct {
+		date time.Time
+		want string
+	}{
+		{time.Date(2024, 1, 11, 9, 0, 0, 0, time.Local), "2024-01-10"},
+		{time.Date(2024, 1, 15, 9, 0, 0, 0, time.Local), "2024-01-12"},
+		{time.Date(2024, 1, 16, 9, 0, 0, 0, time.Local), "2024-01-12"},
+		{time.Date(2024, 1, 14, 9, 0, 0, 0, time.Local), "2024-01-12"},
+	}
+	for _, tt := range tests {
+		got := PreviousBusinessDay(tt.date, holidays).Format("2006-01-02")
+		if got != tt.want {
+			t.Errorf("PreviousBusinessDay(%s) = %s, expected %s", tt.date.Format("2006-01-02"), got, tt.want)
+		}
+	}
+}
+
+func TestNextBusinessDay(t *testing.T) {
+	holidays := []time.Time{time.Date(2024, 1, 15, 0, 0, 0, 0, time.Local)}
+	got := NextBusinessDay(time.Date(2024, 1, 12, 17, 0, 0, 0, time.Local), holidays).Format("2006-01-02")
+	if got != "2024-01-16" {
+		t.Errorf("Expected 2024-01-16, got %s", got)
+	}
+}
+
+// carryoverVault returns a vault holding the fixture note for 2024-01-10 and
+// an empty note for 2024-01-11.
+func carryoverVault(t *testing.T) (*Vault, string, string) {
+	t.Helper()
+	fixture, err := os.ReadFile(filepath.Join("testdata", "vault", "Journal", "Daily", "2024-01-10.md"))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	v := writeFiles(t, map[string]string{
+		".obsidian/daily-notes.json": `{"folder": "Daily"}`,
+		"Daily/2024-01-10.md":        string(fixture),
+		"Daily/2024-01-11.md":        string(readTemplate(t)),
+	})
+	return v, filepath.Join(v.Root, "Daily", "2024-01-10.md"), filepath.Join(v.Root, "Daily", "2024-01-11.md")
+}
+
+func TestFindCarryover(t *testing.T) {
+	v, yesterday, _ := carryoverVault(t)
+
+	path, tasks, err := v.FindCarryover(time.Date(2024, 1, 11, 8, 0, 0, 0, time.Local), nil)
+	if err != nil {
+		t.Fatalf("FindCarryover failed: %v", err)
+	}
+	if path != yesterday {
+		t.Errorf("Expected carryover from %s, got %s", yesterday, path)
+	}
+
+	var texts []string
+	for _, task := range tasks {
+		texts = append(texts, task.Text)
+	}
+	want := "Fuzz tests|Answer the security questionnaire|Ask about the offsite"
+	if strings.Join(texts, "|") != want {
+		t.Errorf("Expected carryover %q, got %q", want, strings.Join(texts, "|"))
+	}
+}
+
+func TestFindCarryover_SkipsMissingDays(t *testing.T) {
+	v, _, today := carryoverVault(t)
+	// Friday the 12th has no note, so Monday the 15th looks further back.
+	path, tasks, err := v.FindCarryover(time.Date(2024, 1, 15, 8, 0, 0, 0, time.Local), nil)
+	if err != nil {
+		t.Fatalf("FindCarryover failed: %v", err)
+	}
+	if path != today || len(tasks) != 0 {
+		t.Errorf("Expected empty carryover from %s, got %s %v", today, path, tasks)
+	}
+}
+
+func TestFindCarryover_SkipsUnmanagedNotes(t *testing.T) {
+	v, yesterday, today := carryoverVault(t)
+	// A note from a template without the managed sections is passed over.
+	if err := os.WriteFile(today, []byte("# Notes\n- [ ] Unmanaged\n"), 0644); err != nil {
+		t.Fatalf("Failed to write note: %v", err)
+	}
+	path, tasks, err := v.FindCarryover(time.Date(2024, 1, 12, 8, 0, 0, 0, time.Local), nil)
+	if err != nil {
+		t.Fatalf("FindCarryover failed: %v", err)
+	}
+	if path != yesterday || len(tasks) != 3 {
+		t.Errorf("Expected carryover from %s, got %s %v", yesterday, path, tasks)
+	}
+}
+
+func TestFindCarryover_NoNote(t *testing.T) {
+	v, _, _ := carryoverVault(t)
+	path, tasks, err := v.FindCarryover(time.Date(2024, 3, 1, 8, 0, 0, 0, time.Local), nil)
+	if err != nil {
+		t.Fatalf("FindCarryover failed: %v", err)
+	}
+	if path != "" || len(tasks) != 0 {
+		t.Errorf("Expected no carryover, got %s %v", path, tasks)
+	}
+}
+
+func TestApplyCarryover(t *testing.T) {
+	v, yesterday, today := carryoverVault(t)
+	_, tasks, err := v.FindCarryover(time.Date(2024, 1, 11, 8, 0, 0, 0, time.Local), nil)
+	if err != nil || len(tasks) != 3 {
+		t.Fatalf("FindCarryover failed: %v %v", tasks, err)
+	}
+
+	decisions := []CarryoverDecision{
+		{Task: tasks[0], Action: CarryForward},
+		{Task: tasks[1], Action: Drop},
+		{Task: tasks[2], Action: Defer},
+	}
+	if err := v.ApplyCarryover(yesterday, today, decisions); err != nil {
+		t.Fatalf("ApplyCarryover failed: %v", err)
+	}
+	// Applying again must not add the carried task twice.
+	if err := v.ApplyCarryover(yesterday, today, decisions[:1]); err != nil {
+		t.Fatalf("Second ApplyCarryover failed: %v", err)
+	}
+
+	note, err := v.ReadDailyNote(today)
+	if err != nil {
+		t.Fatalf("ReadDailyNote failed: %v", err)
+	}
+	if note.Section(SectionGoals) != "- [ ] Fuzz tests\n\n" {
+		t.Errorf("Unexpected Goals section: %q", note.Section(SectionGoals))
+	}
+
+	content, err := os.ReadFile(yesterday)
+	if err != nil {
+		t.Fatalf("Failed to read source note: %v", err)
+	}
+	for _, want := range []string{"\t- [>] Fuzz tests\n", "- [-] Answer the security questionnaire\n", "- [?] Ask about the offsite\n"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("Expected source note to contain %q", want)
+		}
+	}
+}
+
+func TestApplyCarryover_SourceChanged(t *testing.T) {
+	v, yesterday, today := carryoverVault(t)
+	_, tasks, err := v.FindCarryover(time.Date(2024, 1, 11, 8, 0, 0, 0, time.Local), nil)
+	if err != nil {
+		t.Fatalf("FindCarryover failed: %v", err)
+	}
+
+	// The task gets ticked in Obsidian before the carryover is applied.
+	content, _ := os.ReadFile(yesterday)
+	content = []byte(strings.Replace(string(content), "- [ ] Fuzz tests", "- [x] Fuzz tests", 1))
+	if err := os.WriteFile(yesterday, content, 0644); err != nil {
+		t.Fatalf("Failed to edit note: %v", err)
+	}
+
+	err = v.ApplyCarryover(yesterday, today, []CarryoverDecision{{Task: tasks[0], Action: CarryForward}})
+	if err == nil {
+		t.Fatal("Expected error for a task changed since it was read")
+	}
+	note, _ := v.ReadDailyNote(today)
+	if note.Section(SectionGoals) != "\n" {
+		t.Errorf("Expected today's Goals to be untouched, got %q", note.Section(SectionGoals))
+	}
+}
+
+func TestApplyCarryover_TargetInvalid(t *testing.T) {
+	v, yesterday, today := carryoverVault(t)
+	_, tasks, err := v.FindCarryover(time.Date(2024, 1, 11, 8, 0, 0, 0, time.Local), nil)
+	if err != nil {
+		t.Fatalf("FindCarryover failed: %v", err)
+	}
+	before, _ := os.ReadFile(yesterday)
+	if err := os.WriteFile(today, []byte("# Notes\n"), 0644); err != nil {
+		t.Fatalf("Failed to write note: %v", err)
+	}
+
+	err = v.ApplyCarryover(yesterday, today, []CarryoverDecision{{Task: tasks[0], Action: CarryForward}})
+	if !errors.Is(err, ErrSectionNotFound) {
+		t.Fatalf("Expected ErrSectionNotFound, got %v", err)
+	}
+	after, _ := os.ReadFile(yesterday)
+	if string(after) != string(before) {
+		t.Error("Expected the source note to be untouched when today's note cannot be updated")
+	}
+}
@@ -0,0 +1,63 @@
+package vault
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ConflictError is returned when a section the planner wants to write was
+// also edited in Obsidian since the note was read.
+type ConflictError struct {
+	Path     string
+	ModTime  time.Time
+	Sections []Section
+}
+
+func (e *ConflictError) Error() string {
+	names := make([]string, len(e.Sections))
+	for i, s := range e.Sections {
+		names[i] = string(s)
+	}
+	return fmt.Sprintf("%s was edited at %s and %s changed on both sides; nothing was written, review the note and plan again",
+		e.Path, e.ModTime.Format("15:04:05"), strings.Join(names, ", "))
+}
+
+// WriteDailyNote applies updates that were prepared against base, the note
+// as it was read earlier. If the file has changed since, the updates are
+// merged into the current version as long as the edits touched other
+// sections; otherwise a *ConflictError is returned and nothing is written.
+func (v *Vault) WriteDailyNote(base *DailyNote, updates SectionUpdates) error {
+	current, err := v.ReadDailyNote(base.Path)
+	if err != nil {
+		return err
+	}
+	if current.Hash != base.Hash {
+		var conflicts []Section
+		for _, s := range ManagedSections {
+			body := updates.get(s)
+			if body == nil || current.Section(s) == base.Section(s) {
+				continue
+			}
+			if normalizeSectionBody(*body, false) == normalizeSectionBody(current.Section(s), false) {
+				// Both sides made the same change.
+				continue
+			}
+			conflicts = append(conflicts, s)
+		}
+		if len(conflicts) > 0 {
+			return &ConflictError{Path: base.Path, ModTime: current.ModTime, Sections: conflicts}
+		}
+	}
+
+	before := current.Bytes()
+	if err := current.Apply(updates); err != nil {
+		return err
+	}
+	after := current.Bytes()
+	if bytes.Equal(before, after) {
+		return nil
+	}
+	return v.writeNote(base.Path, after)
+}
@@ -0,0 +1,85 @@
+package vault
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// editedNote returns the template note as read by the planner, after which
+// edit is applied to the file as if it were changed in Obsidian.
+func editedNote(t *testing.T, edit func(string) string) (*Vault, *DailyNote) {
+	t.Helper()
+	v := writeFiles(t, map[string]string{"Daily/today.md": string(readTemplate(t))})
+	path := filepath.Join(v.Root, "Daily", "today.md")
+	goals := "- [ ] Ship it"
+	if err := v.UpdateDailyNote(path, SectionUpdates{Goals: &goals}); err != nil {
+		t.Fatalf("UpdateDailyNote failed: %v", err)
+	}
+	base, err := v.ReadDailyNote(path)
+	if err != nil {
+		t.Fatalf("ReadDailyNote failed: %v", err)
+	}
+	if edit != nil {
+		content, _ := os.ReadFile(path)
+		if err := os.WriteFile(path, []byte(edit(string(content))), 0644); err != nil {
+			t.Fatalf("Failed to edit note: %v", err)
+		}
+	}
+	return v, base
+}
+
+func TestWriteDailyNote_Unchanged(t *testing.T) {
+	v, base := editedNote(t, nil)
+	if base.Hash == "" || base.ModTime.IsZero() {
+		t.Errorf("Expected the read to record hash and mtime, got %q %v", base.Hash, base.ModTime)
+	}
+	meetings := "- [ ] Standup"
+	if err := v.WriteDailyNote(base, SectionUpdates{Meetings: &meetings}); err != nil {
+		t.Fatalf("WriteDailyNote failed: %v", err)
+	}
+	note, _ := v.ReadDailyNote(base.Path)
+	if note.Section(SectionMeetings) != "- [ ] Standup\n\n" {
+		t.Errorf("Unexpected Meetings: %q", note.Section(SectionMeetings))
+	}
+}
+
+func TestWriteDailyNote_MergesUnrelatedEdits(t *testing.T) {
+	v, base := editedNote(t, func(s string) string {
+		s = strings.Replace(s, "- [ ] Ship it", "- [x] Ship it", 1)
+		return strings.Replace(s, "## Notes\n", "## Notes\nTyped in Obsidian.\n", 1)
+	})
+	meetings := "- [ ] Standup"
+	if err := v.WriteDailyNote(base, SectionUpdates{Meetings: &meetings}); err != nil {
+		t.Fatalf("WriteDailyNote failed: %v", err)
+	}
+	content, _ := os.ReadFile(base.Path)
+	for _, want := range []string{"- [x] Ship it\n", "Typed in Obsidian.\n", "- [ ] Standup\n"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("Expected merged note to contain %q", want)
+		}
+	}
+}
+
+func TestWriteDailyNote_Conflict(t *testing.T) {
+	v, base := editedNote(t, func(s string) string {
+		return strings.Replace(s, "- [ ] Ship it", "- [x] Ship it", 1)
+	})
+	before, _ := os.ReadFile(base.Path)
+
+	goals := "- [ ] Ship it\n- [ ] Review the RFC"
+	meetings := "- [ ] Standup"
+	err := v.WriteDailyNote(base, SectionUpdates{Goals: &goals, Meetings: &meetings})
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("Expected a ConflictError, got %v", err)
+	}
+	if len(conflict.Sections) != 1 || conflict.Sections[0] != SectionGoals {
+		t.Errorf("Expected a conflict on Goals, got %v", conflict.Sections)
+	}
+	if after, _ := os.ReadFile(base.Path); string(after) != string(before) {
+		t.Error("Expected nothing to be written on conflict")
+	}
+}
@@ -0,0 +1,19 @@
+//go:build darwin
+
+package vault
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// createdAt returns when the file at path was created, which Dataview calls
+// file.ctime.
+func createdAt(path string, info os.FileInfo) time.Time {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return info.ModTime()
+	}
+	return time.Unix(st.Birthtimespec.Unix())
+}
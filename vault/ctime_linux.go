@@ -0,0 +1,21 @@
+//go:build linux
+
+package vault
+
+import (
+	"os"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// createdAt returns when the file at path was created, which Dataview calls
+// file.ctime. File systems that do not record it give the modification time.
+func createdAt(path string, info os.FileInfo) time.Time {
+	var st unix.Statx_t
+	err := unix.Statx(unix.AT_FDCWD, path, 0, unix.STATX_BTIME, &st)
+	if err != nil || st.Mask&unix.STATX_BTIME == 0 {
+		return info.ModTime()
+	}
+	return time.Unix(st.Btime.Sec, int64(st.Btime.Nsec))
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !darwin && !windows
+
+package vault
+
+import (
+	"os"
+	"time"
+)
+
+// createdAt stands in for the creation time, which Dataview calls
+// file.ctime, with the modification time where the former is not available.
+func createdAt(path string, info os.FileInfo) time.Time {
+	return info.ModTime()
+}
@@ -0,0 +1,19 @@
+//go:build windows
+
+package vault
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// createdAt returns when the file at path was created, which Dataview calls
+// file.ctime.
+func createdAt(path string, info os.FileInfo) time.Time {
+	data, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return info.ModTime()
+	}
+	return time.Unix(0, data.CreationTime.Nanoseconds())
+}
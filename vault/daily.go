@@ -0,0 +1,99 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultDailyTemplate is used when the vault has no daily note template
+// configured. It carries just the sections the planner manages.
+const defaultDailyTemplate = `---
+tags:
+  - daily-log
+---
+### Goals
+
+### Meetings
+
+### Bonus Items
+
+## Notes
+`
+
+// DailyNotePath returns where the daily note for date lives, following the
+// vault's daily note folder and filename format.
+func (v *Vault) DailyNotePath(date time.Time) (string, error) {
+	settings, err := v.DailyNoteSettings()
+	if err != nil {
+		return "", err
+	}
+	name := formatMoment(date, settings.Format) + ".md"
+	return filepath.Join(v.Root, filepath.FromSlash(strings.Trim(settings.Folder, "/")), filepath.FromSlash(name)), nil
+}
+
+// EnsureDailyNote creates the daily note for date from the vault's daily
+// template when it does not exist yet. It returns the note path and whether
+// the note was created.
+func (v *Vault) EnsureDailyNote(date time.Time) (string, bool, error) {
+	path, err := v.DailyNotePath(date)
+	if err != nil {
+		return "", false, err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return path, false, nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", false, err
+	}
+
+	content, err := v.renderDailyNote(date, strings.TrimSuffix(filepath.Base(path), ".md"))
+	if err != nil {
+		return "", false, err
+	}
+	if err := v.writeNote(path, content); err != nil {
+		return "", false, err
+	}
+	return path, true, nil
+}
+
+func (v *Vault) renderDailyNote(date time.Time, title string) ([]byte, error) {
+	settings, err := v.DailyNoteSettings()
+	if err != nil {
+		return nil, err
+	}
+	templateSettings, err := v.TemplateSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	template := defaultDailyTemplate
+	if settings.Template != "" {
+		path := filepath.Join(v.Root, filepath.FromSlash(settings.Template))
+		if filepath.Ext(path) != ".md" {
+			path += ".md"
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading daily note template: %w", err)
+		}
+		template = string(data)
+	}
+
+	content := []byte(renderTemplate(template, date, title, templateSettings))
+	content = setFrontmatterField(content, "journal-date", date.Format("2006-01-02"))
+
+	// A new note starts with no unplanned work, whatever the template says.
+	note, err := ParseDailyNote(content)
+	if err != nil {
+		// Still create the note; it just is not one the planner can edit.
+		return content, nil
+	}
+	empty := ""
+	if err := note.Apply(SectionUpdates{BonusItems: &empty}); err != nil {
+		return nil, err
+	}
+	return note.Bytes(), nil
+}
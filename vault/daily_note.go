@@ -0,0 +1,250 @@
+package vault
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrSectionNotFound is returned when a daily note is missing one of the
+// managed section headings.
+var ErrSectionNotFound = errors.New("section not found")
+
+// Section is one of the daily note sections the planner is allowed to edit.
+type Section string
+
+const (
+	SectionGoals      Section = "Goals"
+	SectionMeetings   Section = "Meetings"
+	SectionBonusItems Section = "Bonus Items"
+)
+
+// ManagedSections lists the editable sections in template order.
+var ManagedSections = []Section{SectionGoals, SectionMeetings, SectionBonusItems}
+
+// SectionUpdates holds replacement bodies for the managed sections. A nil
+// field leaves that section untouched.
+type SectionUpdates struct {
+	Goals      *string
+	Meetings   *string
+	BonusItems *string
+}
+
+// Set sets the replacement body for s.
+func (u *SectionUpdates) Set(s Section, body *string) {
+	switch s {
+	case SectionGoals:
+		u.Goals = body
+	case SectionMeetings:
+		u.Meetings = body
+	case SectionBonusItems:
+		u.BonusItems = body
+	}
+}
+
+func (u SectionUpdates) get(s Section) *string {
+	switch s {
+	case SectionGoals:
+		return u.Goals
+	case SectionMeetings:
+		return u.Meetings
+	case SectionBonusItems:
+		return u.BonusItems
+	}
+	return nil
+}
+
+// DailyNote is a daily note split into the managed section bodies and the
+// content around them. Joining the parts back together gives the original
+// bytes, so anything the planner does not edit is preserved exactly.
+type DailyNote struct {
+	Path string
+	// ModTime and Hash identify the version that was read, so a later
+	// write can tell whether the file was edited in the meantime.
+	ModTime time.Time
+	Hash    string
+	parts   []notePart
+}
+
+type notePart struct {
+	// section is empty for content the planner must not touch.
+	section Section
+	level   int
+	text    string
+}
+
+func ParseDailyNote(content []byte) (*DailyNote, error) {
+	headings := scanHeadings(content)
+	type located struct {
+		section Section
+		span    sectionSpan
+	}
+	var found []located
+	for _, s := range ManagedSections {
+		span, ok := findSection(content, headings, string(s))
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrSectionNotFound, s)
+		}
+		found = append(found, located{section: s, span: span})
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].span.start < found[j].span.start })
+
+	note := &DailyNote{}
+	offset := 0
+	for _, l := range found {
+		if l.span.start < offset {
+			return nil, fmt.Errorf("section %s is nested inside another managed section", l.section)
+		}
+		note.parts = append(note.parts,
+			notePart{text: string(content[offset:l.span.start])},
+			notePart{section: l.section, level: l.span.level, text: string(content[l.span.start:l.span.end])},
+		)
+		offset = l.span.end
+	}
+	note.parts = append(note.parts, notePart{text: string(content[offset:])})
+	return note, nil
+}
+
+func (v *Vault) ReadDailyNote(path string) (*DailyNote, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	note, err := ParseDailyNote(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	note.Path = path
+	note.ModTime = info.ModTime()
+	note.Hash = hashContent(content)
+	return note, nil
+}
+
+// UpdateDailyNote replaces the given section bodies of the note at path. The
+// file is left alone when the updates would not change it.
+func (v *Vault) UpdateDailyNote(path string, updates SectionUpdates) error {
+	note, err := v.ReadDailyNote(path)
+	if err != nil {
+		return err
+	}
+	before := note.Bytes()
+	if err := note.Apply(updates); err != nil {
+		return err
+	}
+	after := note.Bytes()
+	if bytes.Equal(before, after) {
+		return nil
+	}
+	return v.writeNote(path, after)
+}
+
+func (n *DailyNote) Bytes() []byte {
+	var b bytes.Buffer
+	for _, p := range n.parts {
+		b.WriteString(p.text)
+	}
+	return b.Bytes()
+}
+
+// Section returns the raw body of a managed section, exactly as it appears
+// between its heading and the next one.
+func (n *DailyNote) Section(s Section) string {
+	for _, p := range n.parts {
+		if p.section == s {
+			return p.text
+		}
+	}
+	return ""
+}
+
+// Apply replaces the bodies of the sections set in updates. Bodies are
+// normalised so that applying the same updates again is a no-op, and every
+// body is validated before anything is changed.
+func (n *DailyNote) Apply(updates SectionUpdates) error {
+	replacements := make(map[int]string)
+	for i, p := range n.parts {
+		if p.section == "" {
+			continue
+		}
+		body := updates.get(p.section)
+		if body == nil {
+			continue
+		}
+		if err := validateSectionBody(*body, p.level); err != nil {
+			return fmt.Errorf("section %s: %w", p.section, err)
+		}
+		prev := n.parts[i-1].text
+		text := normalizeSectionBody(*body, strings.HasSuffix(prev, "\r\n"))
+		if prev != "" && !strings.HasSuffix(prev, "\n") {
+			// The heading is the last line of the file and has no newline.
+			text = "\n" + text
+		}
+		replacements[i] = text
+	}
+
+	parts := make([]notePart, len(n.parts))
+	copy(parts, n.parts)
+	for i, text := range replacements {
+		parts[i].text = text
+	}
+	updated := &DailyNote{Path: n.Path, parts: parts}
+	if !sameLayout(updated) {
+		return errors.New("update would change the structure of the note")
+	}
+	n.parts = parts
+	return nil
+}
+
+// sameLayout re-parses the note and checks it splits into the same parts,
+// guarding against bodies that interact with the surrounding Markdown.
+func sameLayout(n *DailyNote) bool {
+	reparsed, err := ParseDailyNote(n.Bytes())
+	if err != nil || len(reparsed.parts) != len(n.parts) {
+		return false
+	}
+	for i := range n.parts {
+		if reparsed.parts[i] != n.parts[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeSectionBody trims a body to its content and leaves exactly one
+// blank line before the next heading, matching the daily template.
+func normalizeSectionBody(body string, crlf bool) string {
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	body = strings.Trim(body, "\n")
+	if strings.TrimSpace(body) == "" {
+		body = "\n"
+	} else {
+		body += "\n\n"
+	}
+	if crlf {
+		body = strings.ReplaceAll(body, "\n", "\r\n")
+	}
+	return body
+}
+
+// validateSectionBody rejects bodies that would move the section boundaries,
+// which would make the edit neither surgical nor repeatable.
+func validateSectionBody(body string, level int) error {
+	headings, openFence := scanMarkdown([]byte(body), 0)
+	for _, h := range headings {
+		if h.level <= level {
+			return fmt.Errorf("body contains heading %q", h.text)
+		}
+	}
+	if openFence {
+		return errors.New("body contains an unterminated code fence")
+	}
+	return nil
+}
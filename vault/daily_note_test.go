@@ -0,0 +1,219 @@
+package vault
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readTemplate(t testing.TB) []byte {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join("testdata", "vault", "templates", "daily-note-template.md"))
+	if err != nil {
+		t.Fatalf("Failed to read daily template: %v", err)
+	}
+	return content
+}
+
+func ptr(s string) *string {
+	return &s
+}
+
+// unmanaged returns the parts of a note the planner must never change.
+func unmanaged(n *DailyNote) []string {
+	var parts []string
+	for _, p := range n.parts {
+		if p.section == "" {
+			parts = append(parts, p.text)
+		}
+	}
+	return parts
+}
+
+func TestParseDailyNote_Template(t *testing.T) {
+	note, err := ParseDailyNote(readTemplate(t))
+	if err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+	for _, s := range ManagedSections {
+		if got := note.Section(s); got != "\n" {
+			t.Errorf("Expected empty %s section, got %q", s, got)
+		}
+	}
+}
+
+func TestParseDailyNote_MissingSection(t *testing.T) {
+	content := []byte("### Goals\n\n### Meetings\n\n## Notes\n")
+	_, err := ParseDailyNote(content)
+	if !errors.Is(err, ErrSectionNotFound) {
+		t.Errorf("Expected ErrSectionNotFound, got %v", err)
+	}
+}
+
+func TestDailyNote_ApplyPreservesOtherContent(t *testing.T) {
+	content := readTemplate(t)
+	note, err := ParseDailyNote(content)
+	if err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+	before := unmanaged(note)
+
+	err = note.Apply(SectionUpdates{
+		Goals:    ptr("- [ ] Ship the editor\n- [ ] Review PRs"),
+		Meetings: ptr("- [ ] Standup\n- [ ] [[2024/01/Roadmap Review|Roadmap Review]]"),
+	})
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	got := string(note.Bytes())
+	if !strings.Contains(got, "### Goals\n- [ ] Ship the editor\n- [ ] Review PRs\n\n### Meetings\n- [ ] Standup\n") {
+		t.Errorf("Sections were not replaced as expected:\n%s", got)
+	}
+	if note.Section(SectionBonusItems) != "\n" {
+		t.Errorf("Expected Bonus Items to be untouched, got %q", note.Section(SectionBonusItems))
+	}
+	after := unmanaged(note)
+	for i := range before {
+		if before[i] != after[i] {
+			t.Errorf("Unmanaged content %d changed:\nbefore: %q\nafter:  %q", i, before[i], after[i])
+		}
+	}
+}
+
+func TestDailyNote_ApplyRejectsHeadings(t *testing.T) {
+	note, err := ParseDailyNote(readTemplate(t))
+	if err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+	original := note.Bytes()
+	err = note.Apply(SectionUpdates{
+		Goals:    ptr("- [ ] Fine"),
+		Meetings: ptr("## Not allowed"),
+	})
+	if err == nil {
+		t.Fatal("Expected error for body containing a heading, got nil")
+	}
+	if !bytes.Equal(note.Bytes(), original) {
+		t.Error("Note changed even though Apply failed")
+	}
+	if err := note.Apply(SectionUpdates{Goals: ptr("```dataview\ntask")}); err == nil {
+		t.Error("Expected error for body with an unterminated fence, got nil")
+	}
+	if err := note.Apply(SectionUpdates{Goals: ptr("- [ ] Task\n#### Detail")}); err != nil {
+		t.Errorf("Expected deeper headings to be allowed, got %v", err)
+	}
+}
+
+func TestDailyNote_ApplyKeepsCRLF(t *testing.T) {
+	content := []byte("### Goals\r\n\r\n### Meetings\r\n\r\n### Bonus Items\r\n\r\n## Notes\r\n")
+	note, err := ParseDailyNote(content)
+	if err != nil {
+		t.Fatalf("Failed to parse note: %v", err)
+	}
+	if err := note.Apply(SectionUpdates{Goals: ptr("- [ ] One\n- [ ] Two")}); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	want := "### Goals\r\n- [ ] One\r\n- [ ] Two\r\n\r\n### Meetings\r\n\r\n### Bonus Items\r\n\r\n## Notes\r\n"
+	if string(note.Bytes()) != want {
+		t.Errorf("Expected %q, got %q", want, note.Bytes())
+	}
+}
+
+func TestVault_UpdateDailyNote(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "2024-01-10.md")
+	if err := os.WriteFile(path, readTemplate(t), 0600); err != nil {
+		t.Fatalf("Failed to write note: %v", err)
+	}
+	v, err := New(dir)
+	if err != nil {
+		t.Fatalf("Failed to open vault: %v", err)
+	}
+	if err := v.UpdateDailyNote(path, SectionUpdates{Goals: ptr("- [ ] Written")}); err != nil {
+		t.Fatalf("UpdateDailyNote failed: %v", err)
+	}
+	note, err := v.ReadDailyNote(path)
+	if err != nil {
+		t.Fatalf("ReadDailyNote failed: %v", err)
+	}
+	if note.Section(SectionGoals) != "- [ ] Written\n\n" {
+		t.Errorf("Expected written goals, got %q", note.Section(SectionGoals))
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected file mode to be preserved, got %v", info.Mode().Perm())
+	}
+}
+
+func addDailyNoteSeeds(f *testing.F) {
+	f.Add(readTemplate(f))
+	f.Add([]byte("### Goals\n- [ ] a\n### Meetings\n### Bonus Items"))
+	f.Add([]byte("---\ntags: [daily-log]\n---\n### Goals\r\n\r\n### Meetings\r\n\r\n### Bonus Items\r\n"))
+	f.Add([]byte("```\n### Goals\n```\n## Goals\n- [x] done\n#### Sub\n## Meetings\n## Bonus Items\n~~~\n## Notes\n~~~\n"))
+}
+
+// FuzzDailyNote_RoundTrip checks that parsing a note and writing it back
+// without edits reproduces the file byte-for-byte.
+func FuzzDailyNote_RoundTrip(f *testing.F) {
+	addDailyNoteSeeds(f)
+	f.Fuzz(func(t *testing.T, content []byte) {
+		note, err := ParseDailyNote(content)
+		if err != nil {
+			return
+		}
+		if !bytes.Equal(note.Bytes(), content) {
+			t.Fatalf("Round trip changed the note:\nbefore: %q\nafter:  %q", content, note.Bytes())
+		}
+		if err := note.Apply(SectionUpdates{}); err != nil {
+			t.Fatalf("Empty update failed: %v", err)
+		}
+		if !bytes.Equal(note.Bytes(), content) {
+			t.Fatalf("Empty update changed the note:\nbefore: %q\nafter:  %q", content, note.Bytes())
+		}
+	})
+}
+
+// FuzzDailyNote_ApplyIdempotent checks that applying the same edit twice is
+// the same as applying it once, and that content outside the managed
+// sections never changes.
+func FuzzDailyNote_ApplyIdempotent(f *testing.F) {
+	addDailyNoteSeeds(f)
+	f.Fuzz(func(t *testing.T, content []byte) {
+		note, err := ParseDailyNote(content)
+		if err != nil {
+			return
+		}
+		before := unmanaged(note)
+		updates := SectionUpdates{
+			Goals:      ptr("- [ ] " + string(content[:min(len(content), 16)])),
+			BonusItems: ptr(""),
+		}
+		if err := note.Apply(updates); err != nil {
+			return
+		}
+		once := note.Bytes()
+		for i, part := range unmanaged(note) {
+			if part != before[i] {
+				t.Fatalf("Unmanaged content changed:\nbefore: %q\nafter:  %q", before[i], part)
+			}
+		}
+
+		again, err := ParseDailyNote(once)
+		if err != nil {
+			t.Fatalf("Failed to parse edited note: %v", err)
+		}
+		if err := again.Apply(updates); err != nil {
+			t.Fatalf("Second apply failed: %v", err)
+		}
+		if !bytes.Equal(again.Bytes(), once) {
+			t.Fatalf("Second apply changed the note:\nonce:  %q\ntwice: %q", once, again.Bytes())
+		}
+	})
+}
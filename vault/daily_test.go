@@ -0,0 +1,205 @@
+package vault
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFiles creates a throwaway vault containing the given files.
+func writeFiles(t *testing.T, files map[string]string) *Vault {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	v, err := New(dir)
+	if err != nil {
+		t.Fatalf("Failed to open vault: %v", err)
+	}
+	return v
+}
+
+func TestFormatMoment(t *testing.T) {
+	date := time.Date(2024, 1, 3, 14, 5, 9, 0, time.UTC)
+	tests := []struct {
+		layout string
+		want   string
+	}{
+		{"YYYY-MM-DD", "2024-01-03"},
+		{"YYYY/MM/YYYY-MM-DD", "2024/01/2024-01-03"},
+		{"dddd, MMMM Do YYYY", "Wednesday, January 3rd 2024"},
+		{"ddd D MMM YY", "Wed 3 Jan 24"},
+		{"gggg-[W]ww", "2024-W01"},
+		{"HH:mm:ss", "14:05:09"},
+		{"h:mm A", "2:05 PM"},
+		{"[Daily Note] YYYY", "Daily Note 2024"},
+	}
+	for _, tt := range tests {
+		if got := formatMoment(date, tt.layout); got != tt.want {
+			t.Errorf("formatMoment(%q) = %q, expected %q", tt.layout, got, tt.want)
+		}
+	}
+}
+
+func TestDailyNotePath(t *testing.T) {
+	v := testVault(t)
+	path, err := v.DailyNotePath(time.Date(2024, 1, 10, 8, 0, 0, 0, time.Local))
+	if err != nil {
+		t.Fatalf("DailyNotePath failed: %v", err)
+	}
+	want := filepath.Join(v.Root, "Journal", "Daily", "2024-01-10.md")
+	if path != want {
+		t.Errorf("Expected %s, got %s", want, path)
+	}
+}
+
+func TestDailyNoteSettings_PeriodicNotes(t *testing.T) {
+	v := writeFiles(t, map[string]string{
+		".obsidian/daily-notes.json":                 `{"folder": "Ignored", "format": "DD-MM-YYYY"}`,
+		".obsidian/plugins/periodic-notes/data.json": `{"daily": {"enabled": true, "folder": "/Journal/", "format": "YYYY/MM/YYYY-MM-DD dddd", "template": "templates/daily"}}`,
+	})
+	settings, err := v.DailyNoteSettings()
+	if err != nil {
+		t.Fatalf("DailyNoteSettings failed: %v", err)
+	}
+	if settings.Folder != "/Journal/" || settings.Template != "templates/daily" {
+		t.Errorf("Expected periodic notes settings, got %+v", settings)
+	}
+	path, err := v.DailyNotePath(time.Date(2024, 1, 10, 0, 0, 0, 0, time.Local))
+	if err != nil {
+		t.Fatalf("DailyNotePath failed: %v", err)
+	}
+	want := filepath.Join(v.Root, "Journal", "2024", "01", "2024-01-10 Wednesday.md")
+	if path != want {
+		t.Errorf("Expected %s, got %s", want, path)
+	}
+}
+
+func TestDailyNoteSettings_Defaults(t *testing.T) {
+	v := writeFiles(t, map[string]string{
+		".obsidian/plugins/periodic-notes/data.json": `{"daily": {"enabled": false, "folder": "Periodic"}}`,
+	})
+	path, err := v.DailyNotePath(time.Date(2024, 1, 10, 0, 0, 0, 0, time.Local))
+	if err != nil {
+		t.Fatalf("DailyNotePath failed: %v", err)
+	}
+	if want := filepath.Join(v.Root, "2024-01-10.md"); path != want {
+		t.Errorf("Expected %s, got %s", want, path)
+	}
+}
+
+func TestEnsureDailyNote(t *testing.T) {
+	template := `---
+tags:
+  - daily-log
+journal-date: 
+created: <% tp.file.creation_date("YYYY-MM-DD HH:mm") %>
+---
+# {{title}}
+Yesterday: [[<% tp.date.now("YYYY-MM-DD", -1) %>]] Tomorrow: [[<% tp.date.tomorrow() %>]]
+Written {{date:dddd}} at {{time}}
+<%* tR += "left alone" %>
+### Goals
+
+### Meetings
+
+### Bonus Items
+- [ ] Template example item
+
+## Notes
+`
+	v := writeFiles(t, map[string]string{
+		".obsidian/daily-notes.json": `{"folder": "Daily", "format": "YYYY-MM-DD", "template": "templates/Daily"}`,
+		".obsidian/templates.json":   `{"folder": "templates", "timeFormat": "h:mm A"}`,
+		"templates/Daily.md":         template,
+	})
+	date := time.Date(2024, 1, 10, 7, 30, 0, 0, time.Local)
+
+	path, created, err := v.EnsureDailyNote(date)
+	if err != nil {
+		t.Fatalf("EnsureDailyNote failed: %v", err)
+	}
+	if !created {
+		t.Error("Expected the note to be created")
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read created note: %v", err)
+	}
+	got := string(content)
+
+	for _, want := range []string{
+		"journal-date: 2024-01-10\n",
+		"created: 2024-01-10 07:30\n",
+		"# 2024-01-10\n",
+		"Yesterday: [[2024-01-09]] Tomorrow: [[2024-01-11]]\n",
+		"Written Wednesday at 7:30 AM\n",
+		`<%* tR += "left alone" %>`,
+		"### Bonus Items\n\n## Notes\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected created note to contain %q, got:\n%s", want, got)
+		}
+	}
+	if strings.Count(got, "journal-date") != 1 {
+		t.Errorf("Expected a single journal-date field, got:\n%s", got)
+	}
+
+	// A second call must leave the existing note alone.
+	if err := os.WriteFile(path, []byte("edited"), 0644); err != nil {
+		t.Fatalf("Failed to edit note: %v", err)
+	}
+	_, created, err = v.EnsureDailyNote(date)
+	if err != nil {
+		t.Fatalf("EnsureDailyNote failed: %v", err)
+	}
+	if created {
+		t.Error("Expected existing note not to be recreated")
+	}
+	if content, _ := os.ReadFile(path); string(content) != "edited" {
+		t.Errorf("Existing note was overwritten: %q", content)
+	}
+}
+
+func TestEnsureDailyNote_DefaultTemplate(t *testing.T) {
+	v := writeFiles(t, map[string]string{})
+	path, created, err := v.EnsureDailyNote(time.Date(2024, 1, 10, 0, 0, 0, 0, time.Local))
+	if err != nil || !created {
+		t.Fatalf("EnsureDailyNote failed: created=%v err=%v", created, err)
+	}
+	note, err := ReadNote(path)
+	if err != nil {
+		t.Fatalf("Failed to read created note: %v", err)
+	}
+	if d, ok := note.Date("journal-date"); !ok || d.Format("2006-01-02") != "2024-01-10" {
+		t.Errorf("Expected journal-date 2024-01-10, got %v", note.Frontmatter["journal-date"])
+	}
+	if _, err := ParseDailyNote(note.Content); err != nil {
+		t.Errorf("Expected created note to have the managed sections, got %v", err)
+	}
+}
+
+func TestSetFrontmatterField(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"# Note\n", "---\njournal-date: 2024-01-10\n---\n# Note\n"},
+		{"---\ntags: []\n---\nbody", "---\ntags: []\njournal-date: 2024-01-10\n---\nbody"},
+		{"---\njournal-date:\n  - old\ntags: []\n---\n", "---\njournal-date: 2024-01-10\ntags: []\n---\n"},
+	}
+	for _, tt := range tests {
+		if got := string(setFrontmatterField([]byte(tt.in), "journal-date", "2024-01-10")); got != tt.want {
+			t.Errorf("setFrontmatterField(%q) = %q, expected %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DeferredNote is the vault note that queues tasks deferred to a later day.
+// It works even when the target daily note does not exist yet, and stays
+// readable and editable in Obsidian.
+const DeferredNote = "Deferred.md"
+
+const deferredHeader = "# Deferred\n\nTasks the planner deferred to a later day. Tick or remove an item once it is handled.\n\n"
+
+// DeferredTask is an open entry in the deferral queue.
+type DeferredTask struct {
+	Text string    `json:"text"`
+	Due  time.Time `json:"due"`
+	From string    `json:"from,omitempty"`
+}
+
+func (d DeferredTask) String() string {
+	if d.From == "" {
+		return d.Text
+	}
+	return fmt.Sprintf("%s (deferred from %s)", d.Text, d.From)
+}
+
+// Defer queues task from the note at path for until and marks the original
+// ">" with a link to the daily note it went to.
+func (v *Vault) Defer(path string, task *Task, until time.Time) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	content, entry, err := v.deferTask(content, path, task, until)
+	if err != nil {
+		return err
+	}
+	queuePath := filepath.Join(v.Root, DeferredNote)
+	queue, err := v.enqueueDeferred(queuePath, []string{entry})
+	if err != nil {
+		return err
+	}
+	return v.writeNotes(noteWrite{path, content}, noteWrite{queuePath, queue})
+}
+
+// deferTask marks task in content, the note at path, as moved to until and
+// returns the updated content with the queue entry to record.
+func (v *Vault) deferTask(content []byte, path string, task *Task, until time.Time) ([]byte, string, error) {
+	target, err := v.DailyNotePath(until)
+	if err != nil {
+		return nil, "", err
+	}
+	text := task.Text
+	content, err = updateTaskLine(content, task, StatusMoved, " (deferred:: "+v.link(target)+")")
+	if err != nil {
+		return nil, "", err
+	}
+	entry := fmt.Sprintf("- [ ] %s (deferred:: %s) (from:: %s)", text, until.Format("2006-01-02"), v.link(path))
+	return content, entry, nil
+}
+
+// enqueueDeferred returns the queue at path with entries appended.
+func (v *Vault) enqueueDeferred(path string, entries []string) ([]byte, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		content = []byte(deferredHeader)
+	} else if err != nil {
+		return nil, err
+	}
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		content = append(content, '\n')
+	}
+	for _, e := range entries {
+		content = append(content, e+"\n"...)
+	}
+	return content, nil
+}
+
+// DeferredTasks returns the open queue entries due on or before date, so an
+// item deferred to a day that was skipped still turns up.
+func (v *Vault) DeferredTasks(date time.Time) ([]DeferredTask, error) {
+	content, err := os.ReadFile(filepath.Join(v.Root, DeferredNote))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local)
+
+	var due []DeferredTask
+	for _, t := range flattenTasks(ParseTasks(content)) {
+		value, ok := t.Fields.Get("deferred")
+		if !ok || !t.Status.Open() {
+			continue
+		}
+		d, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil || d.After(day) {
+			continue
+		}
+		from, _ := t.Fields.Get("from")
+		due = append(due, DeferredTask{Text: StripInlineFields(t.Text), Due: d, From: from})
+	}
+	return due, nil
+}
+
+// link returns a wiki link to the note at path that resolves even when
+// several notes share a file name.
+func (v *Vault) link(path string) string {
+	rel, err := filepath.Rel(v.Root, path)
+	if err != nil {
+		rel = path
+	}
+	rel = strings.TrimSuffix(filepath.ToSlash(rel), ".md")
+	name := filepath.Base(rel)
+	if rel == name {
+		return "[[" + rel + "]]"
+	}
+	return "[[" + rel + "|" + name + "]]"
+}
+
+// ParseDeferDate turns "tomorrow", a weekday name, "next week" or a
+// YYYY-MM-DD date into a day after from.
+func ParseDeferDate(s string, from time.Time) (time.Time, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	switch s {
+	case "tomorrow":
+		return day.AddDate(0, 0, 1), nil
+	case "next week":
+		offset := (int(time.Monday) - int(day.Weekday()) + 7) % 7
+		if offset == 0 {
+			offset = 7
+		}
+		return day.AddDate(0, 0, offset), nil
+	}
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		name := strings.ToLower(wd.String())
+		if s == name || s == name[:3] {
+			offset := (int(wd) - int(day.Weekday()) + 7) % 7
+			if offset == 0 {
+				offset = 7
+			}
+			return day.AddDate(0, 0, offset), nil
+		}
+	}
+	d, err := time.ParseInLocation("2006-01-02", s, from.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot read %q as a date, try tomorrow, a weekday or YYYY-MM-DD", s)
+	}
+	if !d.After(day) {
+		return time.Time{}, fmt.Errorf("%s is not after %s", s, day.Format("2006-01-02"))
+	}
+	return d, nil
+}
@@ -0,0 +1,127 @@
+package vault
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDefer(t *testing.T) {
+	v, _, today := carryoverVault(t)
+	until := time.Date(2024, 1, 18, 0, 0, 0, 0, time.Local)
+
+	goals := "- [ ] Write the migration\n- [ ] Review the RFC"
+	if err := v.UpdateDailyNote(today, SectionUpdates{Goals: &goals}); err != nil {
+		t.Fatalf("UpdateDailyNote failed: %v", err)
+	}
+	note, err := v.ReadDailyNote(today)
+	if err != nil {
+		t.Fatalf("ReadDailyNote failed: %v", err)
+	}
+	task := note.FindTask("review")
+	if task == nil {
+		t.Fatal("Expected to find the review task")
+	}
+	if err := v.Defer(today, task, until); err != nil {
+		t.Fatalf("Defer failed: %v", err)
+	}
+
+	content, _ := os.ReadFile(today)
+	want := "- [>] Review the RFC (deferred:: [[Daily/2024-01-18|2024-01-18]])\n"
+	if !strings.Contains(string(content), want) {
+		t.Errorf("Expected source note to contain %q, got:\n%s", want, content)
+	}
+	queue, _ := os.ReadFile(filepath.Join(v.Root, DeferredNote))
+	want = "- [ ] Review the RFC (deferred:: 2024-01-18) (from:: [[Daily/2024-01-11|2024-01-11]])\n"
+	if !strings.Contains(string(queue), want) {
+		t.Errorf("Expected queue to contain %q, got:\n%s", want, queue)
+	}
+}
+
+func TestDeferredTasks(t *testing.T) {
+	v := writeFiles(t, map[string]string{
+		DeferredNote: deferredHeader +
+			"- [ ] Early (deferred:: 2024-01-16) (from:: [[Daily/2024-01-11|2024-01-11]])\n" +
+			"- [x] Handled (deferred:: 2024-01-17)\n" +
+			"- [ ] Due (deferred:: 2024-01-17)\n" +
+			"- [ ] Later (deferred:: 2024-01-18)\n" +
+			"- [ ] Not deferred\n",
+	})
+
+	due, err := v.DeferredTasks(time.Date(2024, 1, 17, 9, 0, 0, 0, time.Local))
+	if err != nil {
+		t.Fatalf("DeferredTasks failed: %v", err)
+	}
+	if len(due) != 2 {
+		t.Fatalf("Expected 2 due tasks, got %v", due)
+	}
+	if due[0].Text != "Early" || due[0].From != "[[Daily/2024-01-11|2024-01-11]]" {
+		t.Errorf("Unexpected first entry: %+v", due[0])
+	}
+	if due[1].Text != "Due" || due[1].Due.Format("2006-01-02") != "2024-01-17" {
+		t.Errorf("Unexpected second entry: %+v", due[1])
+	}
+}
+
+func TestDeferredTasks_NoQueue(t *testing.T) {
+	v := writeFiles(t, map[string]string{})
+	due, err := v.DeferredTasks(time.Now())
+	if err != nil || due != nil {
+		t.Errorf("Expected no deferred tasks, got %v %v", due, err)
+	}
+}
+
+func TestApplyCarryover_Defer(t *testing.T) {
+	v, yesterday, today := carryoverVault(t)
+	_, tasks, err := v.FindCarryover(time.Date(2024, 1, 11, 8, 0, 0, 0, time.Local), nil)
+	if err != nil || len(tasks) != 3 {
+		t.Fatalf("FindCarryover failed: %v %v", tasks, err)
+	}
+	until := time.Date(2024, 1, 12, 0, 0, 0, 0, time.Local)
+	decisions := []CarryoverDecision{{Task: tasks[2], Action: Defer, Until: until}}
+	if err := v.ApplyCarryover(yesterday, today, decisions); err != nil {
+		t.Fatalf("ApplyCarryover failed: %v", err)
+	}
+
+	content, _ := os.ReadFile(yesterday)
+	if !strings.Contains(string(content), "- [>] Ask about the offsite (deferred:: [[Daily/2024-01-12|2024-01-12]])") {
+		t.Errorf("Expected the deferred task to be marked moved, got:\n%s", content)
+	}
+	due, err := v.DeferredTasks(until)
+	if err != nil || len(due) != 1 || due[0].Text != "Ask about the offsite" {
+		t.Errorf("Expected the task in the queue, got %v %v", due, err)
+	}
+}
+
+func TestParseDeferDate(t *testing.T) {
+	// Wednesday.
+	from := time.Date(2024, 1, 10, 15, 0, 0, 0, time.Local)
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"tomorrow", "2024-01-11"},
+		{"Thursday", "2024-01-11"},
+		{"wed", "2024-01-17"},
+		{"next week", "2024-01-15"},
+		{"2024-02-01", "2024-02-01"},
+	}
+	for _, tt := range tests {
+		got, err := ParseDeferDate(tt.in, from)
+		if err != nil {
+			t.Errorf("ParseDeferDate(%q) failed: %v", tt.in, err)
+			continue
+		}
+		if got.Format("2006-01-02") != tt.want {
+			t.Errorf("ParseDeferDate(%q) = %s, expected %s", tt.in, got.Format("2006-01-02"), tt.want)
+		}
+	}
+
+	for _, in := range []string{"someday", "2024-01-10", "2023-12-31"} {
+		if _, err := ParseDeferDate(in, from); err == nil {
+			t.Errorf("Expected error for %q", in)
+		}
+	}
+}
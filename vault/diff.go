@@ -0,0 +1,210 @@
+package vault
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// DiffOp marks a line of a diff the way unified diffs do.
+type DiffOp byte
+
+const (
+	DiffEqual  DiffOp = ' '
+	DiffInsert DiffOp = '+'
+	DiffDelete DiffOp = '-'
+)
+
+// DiffLine is one line of a hunk. Text keeps its line ending so accepted
+// hunks rebuild the body byte for byte.
+type DiffLine struct {
+	Op   DiffOp
+	Text string
+}
+
+func (l DiffLine) String() string {
+	return string(l.Op) + strings.TrimRight(l.Text, "\r\n")
+}
+
+// Hunk is a run of changes with the unchanged lines around it. OldStart and
+// NewStart count from zero.
+type Hunk struct {
+	OldStart, OldLines int
+	NewStart, NewLines int
+	Lines              []DiffLine
+}
+
+// Header returns the hunk's "@@ -a,b +c,d @@" line.
+func (h Hunk) Header() string {
+	return fmt.Sprintf("@@ -%s +%s @@", hunkRange(h.OldStart, h.OldLines), hunkRange(h.NewStart, h.NewLines))
+}
+
+func hunkRange(start, lines int) string {
+	if lines == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, lines)
+}
+
+// DiffLines compares old and new line by line and groups the changes into
+// hunks. Equal texts give no hunks.
+func DiffLines(old, new string) []Hunk {
+	a, b := splitLines(old), splitLines(new)
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:]. Section bodies are short, so the quadratic table is fine.
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var script []DiffLine
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			script = append(script, DiffLine{Op: DiffEqual, Text: a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			script = append(script, DiffLine{Op: DiffDelete, Text: a[i]})
+			i++
+		default:
+			script = append(script, DiffLine{Op: DiffInsert, Text: b[j]})
+			j++
+		}
+	}
+	return groupHunks(script)
+}
+
+// groupHunks cuts an edit script into hunks, merging changes whose context
+// would overlap.
+func groupHunks(script []DiffLine) []Hunk {
+	var changes []int
+	for k, l := range script {
+		if l.Op != DiffEqual {
+			changes = append(changes, k)
+		}
+	}
+
+	var hunks []Hunk
+	for c := 0; c < len(changes); {
+		last := c
+		for last+1 < len(changes) && changes[last+1]-changes[last] <= 2*diffContext+1 {
+			last++
+		}
+		from := max(changes[c]-diffContext, 0)
+		to := min(changes[last]+diffContext+1, len(script))
+
+		h := Hunk{Lines: script[from:to]}
+		for _, l := range script[:from] {
+			if l.Op != DiffInsert {
+				h.OldStart++
+			}
+			if l.Op != DiffDelete {
+				h.NewStart++
+			}
+		}
+		for _, l := range h.Lines {
+			if l.Op != DiffInsert {
+				h.OldLines++
+			}
+			if l.Op != DiffDelete {
+				h.NewLines++
+			}
+		}
+		hunks = append(hunks, h)
+		c = last + 1
+	}
+	return hunks
+}
+
+// ApplyHunks rebuilds the text the hunks were made from, taking the new side
+// of accepted hunks and the old side of the rest.
+func ApplyHunks(old string, hunks []Hunk, accepted []bool) string {
+	lines := splitLines(old)
+	var b strings.Builder
+	next := 0
+	for k, h := range hunks {
+		for ; next < h.OldStart; next++ {
+			b.WriteString(lines[next])
+		}
+		for _, l := range h.Lines {
+			if l.Op == DiffEqual || (l.Op == DiffInsert) == accepted[k] {
+				b.WriteString(l.Text)
+			}
+		}
+		next = h.OldStart + h.OldLines
+	}
+	for ; next < len(lines); next++ {
+		b.WriteString(lines[next])
+	}
+	return b.String()
+}
+
+// splitLines splits text after each newline, keeping the line endings.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// SectionDiff is the proposed change to one managed section. Old and New are
+// normalised the way Apply writes them, so only real edits show up.
+type SectionDiff struct {
+	Section Section
+	Old     string
+	New     string
+	Hunks   []Hunk
+}
+
+// Body returns the section body with only the accepted hunks applied.
+func (d SectionDiff) Body(accepted []bool) string {
+	return ApplyHunks(d.Old, d.Hunks, accepted)
+}
+
+// Diff previews updates against the note, returning the sections that would
+// change in template order. Bodies are validated as Apply would.
+func (n *DailyNote) Diff(updates SectionUpdates) ([]SectionDiff, error) {
+	var diffs []SectionDiff
+	for _, s := range ManagedSections {
+		body := updates.get(s)
+		if body == nil {
+			continue
+		}
+		for _, p := range n.parts {
+			if p.section != s {
+				continue
+			}
+			if err := validateSectionBody(*body, p.level); err != nil {
+				return nil, fmt.Errorf("section %s: %w", s, err)
+			}
+		}
+		d := SectionDiff{
+			Section: s,
+			Old:     normalizeSectionBody(n.Section(s), false),
+			New:     normalizeSectionBody(*body, false),
+		}
+		d.Hunks = DiffLines(d.Old, d.New)
+		if len(d.Hunks) > 0 {
+			diffs = append(diffs, d)
+		}
+	}
+	return diffs, nil
+}
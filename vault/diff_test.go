@@ -0,0 +1,102 @@
+package vault
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	old := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\n"
+	new := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+
+	hunks := DiffLines(old, new)
+	if len(hunks) != 2 {
+		t.Fatalf("Expected 2 hunks, got %d: %v", len(hunks), hunks)
+	}
+	if hunks[0].Header() != "@@ -1,5 +1,5 @@" {
+		t.Errorf("Unexpected first header %q", hunks[0].Header())
+	}
+	if hunks[1].Header() != "@@ -10,3 +10,4 @@" {
+		t.Errorf("Unexpected second header %q", hunks[1].Header())
+	}
+
+	var lines []string
+	for _, l := range hunks[0].Lines {
+		lines = append(lines, l.String())
+	}
+	want := " a|-b|+B| c| d| e"
+	if strings.Join(lines, "|") != want {
+		t.Errorf("Expected lines %q, got %q", want, strings.Join(lines, "|"))
+	}
+
+	if got := ApplyHunks(old, hunks, []bool{true, false}); got != "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\n" {
+		t.Errorf("Unexpected partial apply: %q", got)
+	}
+	if got := ApplyHunks(old, hunks, []bool{false, true}); got != "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n" {
+		t.Errorf("Unexpected partial apply: %q", got)
+	}
+}
+
+func TestDiffLines_Equal(t *testing.T) {
+	if hunks := DiffLines("a\nb\n", "a\nb\n"); len(hunks) != 0 {
+		t.Errorf("Expected no hunks, got %v", hunks)
+	}
+}
+
+func TestDailyNote_Diff(t *testing.T) {
+	note, err := ParseDailyNote(readTemplate(t))
+	if err != nil {
+		t.Fatalf("ParseDailyNote failed: %v", err)
+	}
+	goals := "- [ ] Ship it\n- [ ] Review the RFC"
+	same := note.Section(SectionMeetings)
+	diffs, err := note.Diff(SectionUpdates{Goals: &goals, Meetings: &same})
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if len(diffs) != 1 || diffs[0].Section != SectionGoals {
+		t.Fatalf("Expected only Goals to change, got %v", diffs)
+	}
+
+	body := diffs[0].Body([]bool{true})
+	if err := note.Apply(SectionUpdates{Goals: &body}); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if note.Section(SectionGoals) != goals+"\n\n" {
+		t.Errorf("Unexpected Goals after apply: %q", note.Section(SectionGoals))
+	}
+
+	bad := "## Not a body"
+	if _, err := note.Diff(SectionUpdates{BonusItems: &bad}); err == nil {
+		t.Error("Expected error for a body with a heading")
+	}
+}
+
+func FuzzApplyHunks(f *testing.F) {
+	f.Add("a\nb\nc\n", "a\nc\nd\n")
+	f.Add("", "x\n")
+	f.Add("- [ ] one\n- [x] two", "- [ ] one\n\n- [ ] three\n")
+	f.Fuzz(func(t *testing.T, old, new string) {
+		hunks := DiffLines(old, new)
+		all := make([]bool, len(hunks))
+		none := make([]bool, len(hunks))
+		for i := range hunks {
+			all[i] = true
+		}
+		if got := ApplyHunks(old, hunks, all); got != new {
+			t.Fatalf("Accepting every hunk gave %q, expected %q", got, new)
+		}
+		if got := ApplyHunks(old, hunks, none); got != old {
+			t.Fatalf("Rejecting every hunk gave %q, expected %q", got, old)
+		}
+		for i, h := range hunks {
+			// Accepting one hunk must change exactly the lines it covers.
+			one := make([]bool, len(hunks))
+			one[i] = true
+			got := splitLines(ApplyHunks(old, hunks, one))
+			if len(got) != len(splitLines(old))-h.OldLines+h.NewLines {
+				t.Fatalf("Accepting hunk %d gave %d lines", i, len(got))
+			}
+		}
+	})
+}
@@ -0,0 +1,156 @@
+package vault
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Fields are Dataview fields keyed by their normalised name: lower case with
+// spaces turned into dashes, the form Dataview also exposes.
+type Fields map[string]string
+
+var (
+	// bracketField matches the [key:: value] and (key:: value) forms, which
+	// can appear anywhere in a line.
+	bracketField = regexp.MustCompile(`\[([^\[\]()]+?)::\s*([^\[\]]*?)\s*\]|\(([^\[\]()]+?)::\s*([^()]*?)\s*\)`)
+	// lineField matches a "key:: value" field that takes up the whole line.
+	lineField = regexp.MustCompile(`^([^\[\]():]+?)::\s*(.*?)\s*$`)
+	// inlineTag matches a #tag in the text of a note, which may be nested
+	// like #followup/vendor.
+	inlineTag = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{N}_/-]+)`)
+)
+
+func fieldKey(key string) string {
+	key = strings.Trim(strings.TrimSpace(key), "*_")
+	return strings.ToLower(strings.Join(strings.Fields(key), "-"))
+}
+
+// Get returns the value of key, normalising the name the same way.
+func (f Fields) Get(key string) (string, bool) {
+	v, ok := f[fieldKey(key)]
+	return v, ok
+}
+
+func (f Fields) add(key, value string) {
+	key = fieldKey(key)
+	if key == "" {
+		return
+	}
+	// Like Dataview, the first occurrence wins for display.
+	if _, ok := f[key]; !ok {
+		f[key] = value
+	}
+}
+
+// ParseInlineFields reads the Dataview inline fields in a line of text, such
+// as a task. It returns nil when there are none.
+func ParseInlineFields(text string) Fields {
+	fields := Fields{}
+	for _, m := range bracketField.FindAllStringSubmatch(text, -1) {
+		if m[1] != "" {
+			fields.add(m[1], m[2])
+		} else {
+			fields.add(m[3], m[4])
+		}
+	}
+	rest := strings.TrimSpace(bracketField.ReplaceAllString(text, ""))
+	if m := lineField.FindStringSubmatch(rest); m != nil {
+		fields.add(m[1], m[2])
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+// StripInlineFields removes the bracketed fields from text, leaving what a
+// reader sees as the task itself.
+func StripInlineFields(text string) string {
+	return strings.Join(strings.Fields(bracketField.ReplaceAllString(text, "")), " ")
+}
+
+// Fields returns the page-level fields of the note: its frontmatter and the
+// inline fields written on their own line in the body. Frontmatter wins when
+// both set the same key.
+func (n *Note) Fields() Fields {
+	fields := Fields{}
+	for k, v := range n.Frontmatter {
+		if s, ok := fieldValue(v); ok {
+			fields.add(k, s)
+		}
+	}
+	walkLines(n.Content, frontmatterEnd(n.Content), func(l mdLine) {
+		if l.code {
+			return
+		}
+		if _, _, ok := listItem(l.text); ok {
+			// Fields on list items belong to the item.
+			return
+		}
+		for k, v := range ParseInlineFields(l.trimmed) {
+			fields.add(k, v)
+		}
+	})
+	return fields
+}
+
+// HasTag reports whether the note is tagged tag, or a tag nested under it,
+// in its frontmatter or its text, which is what Dataview's FROM #tag
+// matches. Tags are compared ignoring case.
+func (n *Note) HasTag(tag string) bool {
+	tag = strings.ToLower(strings.TrimPrefix(tag, "#"))
+	matches := func(t string) bool {
+		t = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(t), "#"))
+		return t == tag || strings.HasPrefix(t, tag+"/")
+	}
+	for _, key := range []string{"tags", "tag"} {
+		switch v := n.Frontmatter[key].(type) {
+		case string:
+			for _, t := range strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' }) {
+				if matches(t) {
+					return true
+				}
+			}
+		case []any:
+			for _, t := range v {
+				if s, ok := t.(string); ok && matches(s) {
+					return true
+				}
+			}
+		}
+	}
+	found := false
+	walkLines(n.Content, frontmatterEnd(n.Content), func(l mdLine) {
+		if found || l.code {
+			return
+		}
+		for _, m := range inlineTag.FindAllStringSubmatch(l.text, -1) {
+			found = found || matches(m[1])
+		}
+	})
+	return found
+}
+
+// fieldValue renders a frontmatter value as Dataview would display it.
+// Empty values are left out since Dataview treats them as unset.
+func fieldValue(v any) (string, bool) {
+	switch v := v.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, v != ""
+	case time.Time:
+		return v.Format("2006-01-02"), true
+	case []any:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := fieldValue(item); ok {
+				parts = append(parts, s)
+			}
+		}
+		return strings.Join(parts, ", "), len(parts) > 0
+	}
+	return fmt.Sprint(v), true
+}
@@ -0,0 +1,133 @@
+package vault
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseInlineFields(t *testing.T) {
+	tests := []struct {
+		text string
+		want Fields
+	}{
+		{"Plain task", nil},
+		{"Meeting at 10:00, see https://example.com", nil},
+		{"fire:: prod alert on the sync job", Fields{"fire": "prod alert on the sync job"}},
+		{"Review PR 42 (review:: backend) [due:: 2024-01-12]", Fields{"review": "backend", "due": "2024-01-12"}},
+		{"Ask (deferred:: [[Daily/2024-01-18|2024-01-18]])", Fields{"deferred": "[[Daily/2024-01-18|2024-01-18]]"}},
+		{"**Follow Up Date**:: 2024-02-01", Fields{"follow-up-date": "2024-02-01"}},
+		{"Link to [[Some Note]] and [x] box", nil},
+	}
+	for _, tt := range tests {
+		got := ParseInlineFields(tt.text)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseInlineFields(%q) = %v, expected %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestStripInlineFields(t *testing.T) {
+	got := StripInlineFields("Review PR 42 (review:: backend) [due:: 2024-01-12] today")
+	if got != "Review PR 42 today" {
+		t.Errorf("Expected stripped text, got %q", got)
+	}
+}
+
+func TestParseTasks_Fields(t *testing.T) {
+	tasks := ParseTasks([]byte("### Bonus Items\n- [f] Sync job down (fire:: prod)\n"))
+	if len(tasks) != 1 {
+		t.Fatalf("Expected one task, got %v", tasks)
+	}
+	if fire, ok := tasks[0].Fields.Get("Fire"); !ok || fire != "prod" {
+		t.Errorf("Expected fire field, got %v", tasks[0].Fields)
+	}
+}
+
+func TestNote_Fields(t *testing.T) {
+	v := writeFiles(t, map[string]string{
+		"Decision.md": "---\nrole: architect\nfollowup-date: 2024-01-12\ntopics:\n  - caching\n  - sync\nfire:\n---\n" +
+			"status:: open\n- [ ] task:: not a page field\n```\ncode:: ignored\n```\n",
+	})
+	note, err := ReadNote(filepath.Join(v.Root, "Decision.md"))
+	if err != nil {
+		t.Fatalf("ReadNote failed: %v", err)
+	}
+	want := Fields{
+		"role":          "architect",
+		"followup-date": "2024-01-12",
+		"topics":        "caching, sync",
+		"status":        "open",
+	}
+	if got := note.Fields(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestWeekAnnotations(t *testing.T) {
+	v := writeFiles(t, map[string]string{
+		".obsidian/daily-notes.json": `{"folder": "Daily"}`,
+		"Daily/2024-01-09.md":        "### Goals\n- [x] Review PR 42 (review:: backend)\n### Bonus Items\n- [f] Sync job down\n",
+		"Daily/2024-01-10.md":        "### Bonus Items\n- [ ] fire:: cache stampede\n",
+		"Daily/2024-01-16.md":        "### Bonus Items\n- [ ] Next week (fire:: later)\n",
+		"Decisions/Cache.md":         "---\nfollowup-date: 2024-01-11\nstatus: open\nrole: architect\ntags: [followup]\n---\n",
+		"Decisions/Later.md":         "---\nfollowup-date: 2024-03-01\nrole: architect\n---\n#followup\n",
+		"Decisions/Untagged.md":      "---\nfollowup-date: 2024-01-11\nrole: architect\n---\n",
+		"Decisions/Other.md":         "---\nfollowup-date: 2024-01-11\ntags: [followup]\n---\n",
+	})
+
+	a, err := v.WeekAnnotations(time.Date(2024, 1, 10, 9, 0, 0, 0, time.Local))
+	if err != nil {
+		t.Fatalf("WeekAnnotations failed: %v", err)
+	}
+	if len(a.Fires) != 2 || a.Fires[0].Text != "Sync job down" || a.Fires[1].Value != "cache stampede" {
+		t.Errorf("Unexpected fires: %v", a.Fires)
+	}
+	if len(a.Reviews) != 1 || a.Reviews[0].Text != "Review PR 42" || !a.Reviews[0].Done || a.Reviews[0].Value != "backend" {
+		t.Errorf("Unexpected reviews: %v", a.Reviews)
+	}
+	if len(a.FollowUps) != 1 || a.FollowUps[0].Text != "Cache" || a.FollowUps[0].Note != "[[Decisions/Cache|Cache]]" {
+		t.Errorf("Unexpected follow-ups: %v", a.FollowUps)
+	}
+}
+
+func TestWeekAnnotations_CreatedThisWeek(t *testing.T) {
+	v := writeFiles(t, map[string]string{
+		".obsidian/daily-notes.json": `{"folder": "Daily"}`,
+		"Incidents/Outage.md":        "---\nfire: database failover\n---\n",
+		"Design/Sync.md":             "---\nrole: architect\ntopics: caching, sync\n---\n",
+		"Design/Review.md":           "architect:: split the sync job\n",
+		"Daily/Log.md":               "---\nrole: architect\ntags: daily-log\n---\n",
+		"templates/Fire.md":          "---\nfire: template\n---\n",
+	})
+	// Dataview goes by creation time, so a later edit does not move a note
+	// into another week.
+	outage := filepath.Join(v.Root, "Incidents", "Outage.md")
+	old := time.Now().AddDate(-1, 0, 0)
+	if err := os.Chtimes(outage, old, old); err != nil {
+		t.Fatalf("Failed to set times: %v", err)
+	}
+	info, err := os.Stat(outage)
+	if err != nil {
+		t.Fatalf("Failed to stat note: %v", err)
+	}
+	btime := !createdAt(outage, info).Equal(info.ModTime())
+
+	a, err := v.WeekAnnotations(time.Now())
+	if err != nil {
+		t.Fatalf("WeekAnnotations failed: %v", err)
+	}
+	if btime && (len(a.Fires) != 1 || a.Fires[0].Value != "database failover") {
+		t.Errorf("Unexpected fires: %v", a.Fires)
+	}
+	texts := map[string]string{}
+	for _, item := range a.Architecture {
+		texts[item.Text] = item.Value
+	}
+	want := map[string]string{"Sync": "caching, sync", "Review": "split the sync job"}
+	if !reflect.DeepEqual(texts, want) {
+		t.Errorf("Expected architectural work %v, got %v", want, texts)
+	}
+}
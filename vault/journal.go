@@ -0,0 +1,250 @@
+package vault
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// ErrChangedSinceWrite is returned when undoing a write would overwrite
+// edits made to the note after the planner wrote it.
+var ErrChangedSinceWrite = errors.New("note changed since the planner wrote it")
+
+const journalFile = "journal.jsonl"
+
+// Journal records every note the planner writes, with a copy of the previous
+// version, so the writes can be undone.
+type Journal struct {
+	Dir string
+}
+
+// JournalEntry is one write. Backup names the copy of the previous version
+// in the journal directory and is empty when the write created the note.
+// Operation is shared by the writes of one change, such as a carryover
+// updating three notes; entries recorded before it existed have none.
+type JournalEntry struct {
+	ID         string    `json:"id"`
+	Operation  string    `json:"operation,omitempty"`
+	Time       time.Time `json:"time"`
+	Path       string    `json:"path"`
+	BeforeHash string    `json:"before_hash,omitempty"`
+	AfterHash  string    `json:"after_hash"`
+	Backup     string    `json:"backup,omitempty"`
+}
+
+func NewJournal(dir string) *Journal {
+	return &Journal{Dir: dir}
+}
+
+// newJournalID returns a new entry or operation id. The random suffix keeps
+// ids apart where the clock is too coarse to, as on Windows.
+func newJournalID() string {
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return strconv.FormatInt(time.Now().UnixNano(), 36) + "-" + hex.EncodeToString(suffix)
+}
+
+// change returns the operation the entry belongs to.
+func (e JournalEntry) change() string {
+	if e.Operation == "" {
+		return e.ID
+	}
+	return e.Operation
+}
+
+func hashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// record stores before, the previous content of path or nil if there was
+// none, and appends an entry for the write of after as part of operation.
+// It is called before the write, which forget takes back if it fails.
+func (j *Journal) record(operation, path string, before, after []byte) (JournalEntry, error) {
+	if err := os.MkdirAll(j.Dir, 0700); err != nil {
+		return JournalEntry{}, err
+	}
+	entry := JournalEntry{
+		ID:        newJournalID(),
+		Operation: operation,
+		Time:      time.Now(),
+		Path:      path,
+		AfterHash: hashContent(after),
+	}
+	if before != nil {
+		entry.BeforeHash = hashContent(before)
+		entry.Backup = entry.ID + filepath.Ext(path)
+		if err := os.WriteFile(filepath.Join(j.Dir, entry.Backup), before, 0600); err != nil {
+			return entry, err
+		}
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return entry, err
+	}
+	f, err := os.OpenFile(filepath.Join(j.Dir, journalFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return entry, err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return entry, err
+	}
+	return entry, f.Close()
+}
+
+// forget removes an entry whose write did not happen.
+func (j *Journal) forget(e JournalEntry) error {
+	entries, err := j.Entries()
+	if err != nil {
+		return err
+	}
+	kept := slices.DeleteFunc(entries, func(k JournalEntry) bool { return k.ID == e.ID })
+	if err := j.rewrite(kept); err != nil {
+		return err
+	}
+	if e.Backup != "" {
+		os.Remove(filepath.Join(j.Dir, e.Backup))
+	}
+	return nil
+}
+
+// Entries returns the recorded writes, oldest first.
+func (j *Journal) Entries() ([]JournalEntry, error) {
+	data, err := os.ReadFile(filepath.Join(j.Dir, journalFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []JournalEntry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var e JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%s: %w", journalFile, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// Undo reverts the last n changes, newest first, restoring every note each
+// one wrote. It stops at the first change with a note that was edited after
+// the planner wrote it, leaving that change whole, and returns the entries
+// undone so far with ErrChangedSinceWrite. Undone entries are removed from
+// the journal.
+func (j *Journal) Undo(n int) ([]JournalEntry, error) {
+	entries, err := j.Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	var undone []JournalEntry
+	var undoErr error
+	end := len(entries)
+changes:
+	for ; n > 0 && end > 0; n-- {
+		start := end - 1
+		for start > 0 && entries[start-1].change() == entries[end-1].change() {
+			start--
+		}
+		change := entries[start:end]
+		if err := j.checkChange(change); err != nil {
+			undoErr = err
+			break
+		}
+		for i := len(change) - 1; i >= 0; i-- {
+			if err := j.restore(change[i]); err != nil {
+				undoErr = err
+				break changes
+			}
+			undone = append(undone, change[i])
+		}
+		end = start
+	}
+	if len(undone) > 0 {
+		if err := j.rewrite(entries[:len(entries)-len(undone)]); err != nil {
+			return undone, err
+		}
+		for _, e := range undone {
+			if e.Backup != "" {
+				os.Remove(filepath.Join(j.Dir, e.Backup))
+			}
+		}
+	}
+	return undone, undoErr
+}
+
+// checkChange makes sure every note of a change is still as the planner
+// left it, before any of them is restored.
+func (j *Journal) checkChange(change []JournalEntry) error {
+	seen := make(map[string]bool)
+	for i := len(change) - 1; i >= 0; i-- {
+		e := change[i]
+		if seen[e.Path] {
+			// An earlier write the later one replaced.
+			continue
+		}
+		seen[e.Path] = true
+		if err := unchangedSinceWrite(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func unchangedSinceWrite(e JournalEntry) error {
+	current, err := os.ReadFile(e.Path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err != nil || hashContent(current) != e.AfterHash {
+		return fmt.Errorf("%w: %s", ErrChangedSinceWrite, e.Path)
+	}
+	return nil
+}
+
+func (j *Journal) restore(e JournalEntry) error {
+	if err := unchangedSinceWrite(e); err != nil {
+		return err
+	}
+	if e.Backup == "" {
+		return os.Remove(e.Path)
+	}
+	before, err := os.ReadFile(filepath.Join(j.Dir, e.Backup))
+	if err != nil {
+		return err
+	}
+	if hashContent(before) != e.BeforeHash {
+		return fmt.Errorf("backup %s does not match the journal", e.Backup)
+	}
+	return atomicWrite(e.Path, before)
+}
+
+func (j *Journal) rewrite(entries []JournalEntry) error {
+	var b bytes.Buffer
+	for _, e := range entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		b.Write(append(line, '\n'))
+	}
+	return atomicWrite(filepath.Join(j.Dir, journalFile), b.Bytes())
+}
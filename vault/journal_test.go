@@ -0,0 +1,139 @@
+package vault
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func journalVault(t *testing.T) *Vault {
+	t.Helper()
+	v := writeFiles(t, map[string]string{"Note.md": "first\n"})
+	v.Journal = NewJournal(filepath.Join(t.TempDir(), "history"))
+	return v
+}
+
+func TestJournal_Undo(t *testing.T) {
+	v := journalVault(t)
+	path := filepath.Join(v.Root, "Note.md")
+	created := filepath.Join(v.Root, "New.md")
+
+	if err := v.writeNote(path, []byte("second\n")); err != nil {
+		t.Fatalf("writeNote failed: %v", err)
+	}
+	if err := v.writeNote(path, []byte("third\n")); err != nil {
+		t.Fatalf("writeNote failed: %v", err)
+	}
+	if err := v.writeNote(created, []byte("new\n")); err != nil {
+		t.Fatalf("writeNote failed: %v", err)
+	}
+
+	entries, err := v.Journal.Entries()
+	if err != nil || len(entries) != 3 {
+		t.Fatalf("Expected 3 journal entries, got %v %v", entries, err)
+	}
+	if entries[0].BeforeHash != hashContent([]byte("first\n")) || entries[0].AfterHash != hashContent([]byte("second\n")) {
+		t.Errorf("Unexpected hashes in %+v", entries[0])
+	}
+
+	undone, err := v.Journal.Undo(2)
+	if err != nil || len(undone) != 2 {
+		t.Fatalf("Undo failed: %v %v", undone, err)
+	}
+	if _, err := os.Stat(created); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected the created note to be removed, got %v", err)
+	}
+	if content, _ := os.ReadFile(path); string(content) != "second\n" {
+		t.Errorf("Expected second version, got %q", content)
+	}
+
+	undone, err = v.Journal.Undo(5)
+	if err != nil || len(undone) != 1 {
+		t.Fatalf("Undo failed: %v %v", undone, err)
+	}
+	if content, _ := os.ReadFile(path); string(content) != "first\n" {
+		t.Errorf("Expected first version, got %q", content)
+	}
+	if entries, _ := v.Journal.Entries(); len(entries) != 0 {
+		t.Errorf("Expected an empty journal, got %v", entries)
+	}
+}
+
+func TestJournal_UndoRefusesChangedNote(t *testing.T) {
+	v := journalVault(t)
+	path := filepath.Join(v.Root, "Note.md")
+	if err := v.writeNote(path, []byte("second\n")); err != nil {
+		t.Fatalf("writeNote failed: %v", err)
+	}
+	// Edited in Obsidian after the planner's write.
+	if err := os.WriteFile(path, []byte("second\nmine\n"), 0644); err != nil {
+		t.Fatalf("Failed to edit note: %v", err)
+	}
+
+	undone, err := v.Journal.Undo(1)
+	if !errors.Is(err, ErrChangedSinceWrite) || len(undone) != 0 {
+		t.Fatalf("Expected ErrChangedSinceWrite, got %v %v", undone, err)
+	}
+	if content, _ := os.ReadFile(path); string(content) != "second\nmine\n" {
+		t.Errorf("Expected the edit to be kept, got %q", content)
+	}
+	if entries, _ := v.Journal.Entries(); len(entries) != 1 {
+		t.Errorf("Expected the entry to stay in the journal, got %v", entries)
+	}
+}
+
+func TestJournal_UndoOperation(t *testing.T) {
+	v := journalVault(t)
+	path := filepath.Join(v.Root, "Note.md")
+	other := filepath.Join(v.Root, "Other.md")
+	if err := v.writeNote(path, []byte("second\n")); err != nil {
+		t.Fatalf("writeNote failed: %v", err)
+	}
+	if err := v.writeNotes(noteWrite{path, []byte("third\n")}, noteWrite{other, []byte("other\n")}); err != nil {
+		t.Fatalf("writeNotes failed: %v", err)
+	}
+
+	// One undo reverts both notes of the last change.
+	undone, err := v.Journal.Undo(1)
+	if err != nil || len(undone) != 2 {
+		t.Fatalf("Expected both writes undone, got %v %v", undone, err)
+	}
+	if content, _ := os.ReadFile(path); string(content) != "second\n" {
+		t.Errorf("Expected second version, got %q", content)
+	}
+	if _, err := os.Stat(other); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected the created note to be removed, got %v", err)
+	}
+}
+
+func TestJournal_UndoKeepsChangeWhole(t *testing.T) {
+	v := journalVault(t)
+	path := filepath.Join(v.Root, "Note.md")
+	other := filepath.Join(v.Root, "Other.md")
+	if err := v.writeNotes(noteWrite{path, []byte("second\n")}, noteWrite{other, []byte("other\n")}); err != nil {
+		t.Fatalf("writeNotes failed: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("second\nmine\n"), 0644); err != nil {
+		t.Fatalf("Failed to edit note: %v", err)
+	}
+
+	undone, err := v.Journal.Undo(1)
+	if !errors.Is(err, ErrChangedSinceWrite) || len(undone) != 0 {
+		t.Fatalf("Expected ErrChangedSinceWrite, got %v %v", undone, err)
+	}
+	if content, _ := os.ReadFile(other); string(content) != "other\n" {
+		t.Errorf("Expected the other note of the change to be kept, got %q", content)
+	}
+}
+
+func TestNewJournalID_Unique(t *testing.T) {
+	seen := make(map[string]bool)
+	for range 1000 {
+		id := newJournalID()
+		if seen[id] {
+			t.Fatalf("Expected unique ids, got %s twice", id)
+		}
+		seen[id] = true
+	}
+}
@@ -0,0 +1,137 @@
+package vault
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// momentTokens are the Moment.js format tokens Obsidian uses for note names
+// and template dates, longest first so "YYYY" wins over "YY".
+var momentTokens = []string{
+	"YYYY", "GGGG", "gggg", "MMMM", "dddd", "DDDD",
+	"MMM", "ddd", "DDD",
+	"YY", "MM", "DD", "Do", "dd", "WW", "ww", "HH", "hh", "mm", "ss",
+	"Q", "M", "D", "d", "E", "e", "W", "w", "H", "h", "m", "s", "A", "a", "X",
+}
+
+// formatMoment formats t using a Moment.js layout such as "YYYY-MM-DD" or
+// "YYYY/MM/dddd, MMMM Do". Text in square brackets is copied literally.
+func formatMoment(t time.Time, layout string) string {
+	var b strings.Builder
+	for i := 0; i < len(layout); {
+		if layout[i] == '[' {
+			if end := strings.IndexByte(layout[i:], ']'); end > 0 {
+				b.WriteString(layout[i+1 : i+end])
+				i += end + 1
+				continue
+			}
+		}
+		matched := false
+		for _, token := range momentTokens {
+			if strings.HasPrefix(layout[i:], token) {
+				b.WriteString(formatMomentToken(t, token))
+				i += len(token)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			b.WriteByte(layout[i])
+			i++
+		}
+	}
+	return b.String()
+}
+
+func formatMomentToken(t time.Time, token string) string {
+	isoYear, isoWeek := t.ISOWeek()
+	switch token {
+	case "YYYY":
+		return fmt.Sprintf("%04d", t.Year())
+	case "YY":
+		return fmt.Sprintf("%02d", t.Year()%100)
+	case "GGGG", "gggg":
+		return fmt.Sprintf("%04d", isoYear)
+	case "Q":
+		return strconv.Itoa((int(t.Month())-1)/3 + 1)
+	case "MMMM":
+		return t.Month().String()
+	case "MMM":
+		return t.Month().String()[:3]
+	case "MM":
+		return fmt.Sprintf("%02d", int(t.Month()))
+	case "M":
+		return strconv.Itoa(int(t.Month()))
+	case "DDDD":
+		return fmt.Sprintf("%03d", t.YearDay())
+	case "DDD":
+		return strconv.Itoa(t.YearDay())
+	case "DD":
+		return fmt.Sprintf("%02d", t.Day())
+	case "D":
+		return strconv.Itoa(t.Day())
+	case "Do":
+		return ordinal(t.Day())
+	case "dddd":
+		return t.Weekday().String()
+	case "ddd":
+		return t.Weekday().String()[:3]
+	case "dd":
+		return t.Weekday().String()[:2]
+	case "d", "e":
+		return strconv.Itoa(int(t.Weekday()))
+	case "E":
+		return strconv.Itoa(int(t.Weekday()+6)%7 + 1)
+	case "WW", "ww":
+		return fmt.Sprintf("%02d", isoWeek)
+	case "W", "w":
+		return strconv.Itoa(isoWeek)
+	case "HH":
+		return fmt.Sprintf("%02d", t.Hour())
+	case "H":
+		return strconv.Itoa(t.Hour())
+	case "hh":
+		return fmt.Sprintf("%02d", hour12(t))
+	case "h":
+		return strconv.Itoa(hour12(t))
+	case "mm":
+		return fmt.Sprintf("%02d", t.Minute())
+	case "m":
+		return strconv.Itoa(t.Minute())
+	case "ss":
+		return fmt.Sprintf("%02d", t.Second())
+	case "s":
+		return strconv.Itoa(t.Second())
+	case "A":
+		return t.Format("PM")
+	case "a":
+		return t.Format("pm")
+	case "X":
+		return strconv.FormatInt(t.Unix(), 10)
+	}
+	return token
+}
+
+func hour12(t time.Time) int {
+	h := t.Hour() % 12
+	if h == 0 {
+		return 12
+	}
+	return h
+}
+
+func ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return strconv.Itoa(n) + suffix
+}
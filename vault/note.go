@@ -0,0 +1,228 @@
+package vault
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Note is a Markdown file read from the vault along with its parsed
+// frontmatter.
+type Note struct {
+	Path        string
+	Frontmatter map[string]any
+	Content     []byte
+}
+
+func ReadNote(path string) (*Note, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fm, err := parseFrontmatter(content)
+	if err != nil {
+		return nil, err
+	}
+	return &Note{
+		Path:        path,
+		Frontmatter: fm,
+		Content:     content,
+	}, nil
+}
+
+// Date returns a frontmatter field as a calendar date.
+func (n *Note) Date(key string) (time.Time, bool) {
+	return parseDate(n.Frontmatter[key])
+}
+
+// frontmatterEnd returns the offset just past the closing --- line, or 0 if
+// the content has no frontmatter block.
+func frontmatterEnd(content []byte) int {
+	if !bytes.HasPrefix(content, []byte("---\n")) && !bytes.HasPrefix(content, []byte("---\r\n")) {
+		return 0
+	}
+	offset := bytes.IndexByte(content, '\n') + 1
+	for offset < len(content) {
+		line, next := nextLine(content, offset)
+		if strings.TrimRight(line, "\r") == "---" {
+			return next
+		}
+		offset = next
+	}
+	return 0
+}
+
+func parseFrontmatter(content []byte) (map[string]any, error) {
+	end := frontmatterEnd(content)
+	fm := map[string]any{}
+	if end == 0 {
+		return fm, nil
+	}
+	start := bytes.IndexByte(content, '\n') + 1
+	closing := bytes.LastIndex(content[:end-1], []byte("\n")) + 1
+	if closing <= start {
+		return fm, nil
+	}
+	if err := yaml.Unmarshal(content[start:closing], &fm); err != nil {
+		return nil, err
+	}
+	if fm == nil {
+		fm = map[string]any{}
+	}
+	return fm, nil
+}
+
+// parseDate accepts the shapes a YAML date can decode into.
+func parseDate(v any) (time.Time, bool) {
+	switch d := v.(type) {
+	case time.Time:
+		return time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.Local), true
+	case string:
+		if len(d) < len("2006-01-02") {
+			return time.Time{}, false
+		}
+		t, err := time.ParseInLocation("2006-01-02", d[:10], time.Local)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// nextLine returns the line starting at offset without its newline, and the
+// offset of the following line.
+func nextLine(content []byte, offset int) (string, int) {
+	i := bytes.IndexByte(content[offset:], '\n')
+	if i < 0 {
+		return string(content[offset:]), len(content)
+	}
+	return string(content[offset : offset+i]), offset + i + 1
+}
+
+type heading struct {
+	level     int
+	text      string
+	start     int
+	bodyStart int
+}
+
+// scanHeadings finds the ATX headings of a note, ignoring frontmatter, fenced
+// code blocks and anything nested in a callout or blockquote.
+func scanHeadings(content []byte) []heading {
+	headings, _ := scanMarkdown(content, frontmatterEnd(content))
+	return headings
+}
+
+// scanMarkdown walks content from offset and reports its headings and whether
+// a code fence was left open at the end.
+func scanMarkdown(content []byte, offset int) ([]heading, bool) {
+	var headings []heading
+	openFence := walkLines(content, offset, func(l mdLine) {
+		if l.code || l.indent >= 4 {
+			return
+		}
+		if level, text, ok := parseHeading(l.trimmed); ok {
+			headings = append(headings, heading{
+				level:     level,
+				text:      text,
+				start:     l.start,
+				bodyStart: l.next,
+			})
+		}
+	})
+	return headings, openFence
+}
+
+type mdLine struct {
+	// text is the line without its line ending, trimmed is text without its
+	// leading spaces and indent is the number of spaces removed.
+	text    string
+	trimmed string
+	indent  int
+	number  int
+	start   int
+	next    int
+	// code is set for fenced code block lines, including the fences.
+	code bool
+}
+
+// walkLines calls fn for every line from offset on, tracking fenced code
+// blocks. It reports whether a fence was left open at the end.
+func walkLines(content []byte, offset int, fn func(mdLine)) bool {
+	var fence string
+	number := bytes.Count(content[:offset], []byte("\n"))
+	for offset < len(content) {
+		raw, next := nextLine(content, offset)
+		line := strings.TrimRight(raw, "\r")
+		trimmed := strings.TrimLeft(line, " ")
+		l := mdLine{
+			text:    line,
+			trimmed: trimmed,
+			indent:  len(line) - len(trimmed),
+			number:  number,
+			start:   offset,
+			next:    next,
+		}
+
+		switch {
+		case fence != "":
+			l.code = true
+			closing := strings.TrimSpace(trimmed)
+			if l.indent < 4 && strings.HasPrefix(closing, fence) && strings.Trim(closing, fence[:1]) == "" {
+				fence = ""
+			}
+		case l.indent < 4 && (strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")):
+			l.code = true
+			fence = trimmed[:3]
+		}
+		fn(l)
+		offset = next
+		number++
+	}
+	return fence != ""
+}
+
+func parseHeading(line string) (int, string, bool) {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 {
+		return 0, "", false
+	}
+	rest := line[level:]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return 0, "", false
+	}
+	text := strings.TrimSpace(rest)
+	text = strings.TrimSpace(strings.TrimRight(text, "#"))
+	return level, text, true
+}
+
+// sectionSpan is the byte range of the body under a heading, ending at the
+// next heading of the same or a higher level.
+type sectionSpan struct {
+	level      int
+	start, end int
+}
+
+func findSection(content []byte, headings []heading, name string) (sectionSpan, bool) {
+	for i, h := range headings {
+		if !strings.EqualFold(h.text, name) {
+			continue
+		}
+		end := len(content)
+		for _, other := range headings[i+1:] {
+			if other.level <= h.level {
+				end = other.start
+				break
+			}
+		}
+		return sectionSpan{level: h.level, start: h.bodyStart, end: end}, true
+	}
+	return sectionSpan{}, false
+}
@@ -0,0 +1,82 @@
+package vault
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+const defaultDailyFormat = "YYYY-MM-DD"
+
+// DailyNoteSettings mirrors the daily note options shared by the core Daily
+// Notes plugin and the Periodic Notes plugin.
+type DailyNoteSettings struct {
+	Folder   string `json:"folder"`
+	Format   string `json:"format"`
+	Template string `json:"template"`
+}
+
+// TemplateSettings mirrors .obsidian/templates.json from the core Templates
+// plugin, which decides how {{date}} and {{time}} render.
+type TemplateSettings struct {
+	DateFormat string `json:"dateFormat"`
+	TimeFormat string `json:"timeFormat"`
+}
+
+type periodicNotesSettings struct {
+	Daily struct {
+		DailyNoteSettings
+		Enabled bool `json:"enabled"`
+	} `json:"daily"`
+}
+
+// DailyNoteSettings reads where daily notes live. Periodic Notes wins when
+// its daily notes are enabled, then the core Daily Notes plugin, then
+// Obsidian's defaults.
+func (v *Vault) DailyNoteSettings() (DailyNoteSettings, error) {
+	settings := DailyNoteSettings{}
+
+	var periodic periodicNotesSettings
+	found, err := v.readConfig(filepath.Join("plugins", "periodic-notes", "data.json"), &periodic)
+	if err != nil {
+		return settings, err
+	}
+	if found && periodic.Daily.Enabled {
+		settings = periodic.Daily.DailyNoteSettings
+	} else if _, err := v.readConfig("daily-notes.json", &settings); err != nil {
+		return settings, err
+	}
+
+	if settings.Format == "" {
+		settings.Format = defaultDailyFormat
+	}
+	return settings, nil
+}
+
+func (v *Vault) TemplateSettings() (TemplateSettings, error) {
+	settings := TemplateSettings{}
+	if _, err := v.readConfig("templates.json", &settings); err != nil {
+		return settings, err
+	}
+	if settings.DateFormat == "" {
+		settings.DateFormat = defaultDailyFormat
+	}
+	if settings.TimeFormat == "" {
+		settings.TimeFormat = "HH:mm"
+	}
+	return settings, nil
+}
+
+// readConfig decodes a file under .obsidian, reporting false when the file
+// does not exist.
+func (v *Vault) readConfig(name string, out any) (bool, error) {
+	data, err := os.ReadFile(filepath.Join(v.Root, ".obsidian", name))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, json.Unmarshal(data, out)
+}
@@ -0,0 +1,146 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// SectionStats is a "completed/total" pair as the templates show it.
+type SectionStats struct {
+	Completed int `json:"completed"`
+	Total     int `json:"total"`
+}
+
+func (s SectionStats) String() string {
+	return fmt.Sprintf("%d/%d", s.Completed, s.Total)
+}
+
+func (s *SectionStats) add(o SectionStats) {
+	s.Completed += o.Completed
+	s.Total += o.Total
+}
+
+// DayStats holds the numbers the daily template's Dataview tables compute.
+// Only leaf tasks in the managed sections count. Totals leave out cancelled,
+// question and in-progress tasks; Moved and Cancelled are counted apart.
+type DayStats struct {
+	Date      time.Time    `json:"date"`
+	Path      string       `json:"path"`
+	Planned   SectionStats `json:"planned"`
+	Meetings  SectionStats `json:"meetings"`
+	Unplanned SectionStats `json:"unplanned"`
+	Moved     int          `json:"moved"`
+	Cancelled int          `json:"cancelled"`
+	Total     int          `json:"total"`
+}
+
+// Stats sums DayStats over a range of days. Days without a note are skipped.
+type Stats struct {
+	Start     time.Time    `json:"start"`
+	End       time.Time    `json:"end"`
+	Days      []DayStats   `json:"days"`
+	Planned   SectionStats `json:"planned"`
+	Meetings  SectionStats `json:"meetings"`
+	Unplanned SectionStats `json:"unplanned"`
+	Moved     int          `json:"moved"`
+	Cancelled int          `json:"cancelled"`
+	Total     int          `json:"total"`
+}
+
+// ComputeDayStats counts the tasks of a daily note.
+func ComputeDayStats(content []byte) DayStats {
+	var s DayStats
+	for _, t := range flattenTasks(ParseTasks(content)) {
+		if !t.Leaf() {
+			continue
+		}
+		var section *SectionStats
+		switch Section(t.Section) {
+		case SectionGoals:
+			section = &s.Planned
+		case SectionMeetings:
+			section = &s.Meetings
+		case SectionBonusItems:
+			section = &s.Unplanned
+		default:
+			continue
+		}
+		if t.Status.Moved() {
+			s.Moved++
+		}
+		if t.Status.Cancelled() {
+			s.Cancelled++
+		}
+		if !t.Status.Counted() {
+			continue
+		}
+		section.Total++
+		s.Total++
+		if t.Status.Completed() {
+			section.Completed++
+		}
+	}
+	return s
+}
+
+// Stats computes the statistics of the daily notes from start to end,
+// inclusive.
+func (v *Vault) Stats(start, end time.Time) (*Stats, error) {
+	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.Local)
+	end = time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.Local)
+	stats := &Stats{Start: start, End: end}
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		path, err := v.DailyNotePath(day)
+		if err != nil {
+			return nil, err
+		}
+		content, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		d := ComputeDayStats(content)
+		d.Date = day
+		d.Path = path
+		stats.Days = append(stats.Days, d)
+
+		stats.Planned.add(d.Planned)
+		stats.Meetings.add(d.Meetings)
+		stats.Unplanned.add(d.Unplanned)
+		stats.Moved += d.Moved
+		stats.Cancelled += d.Cancelled
+		stats.Total += d.Total
+	}
+	return stats, nil
+}
+
+// WeekStats computes the statistics for the week covering date, using the
+// weekly note's dates when there is one.
+func (v *Vault) WeekStats(date time.Time) (*Stats, error) {
+	start, end, err := v.weekRange(date)
+	if err != nil {
+		return nil, err
+	}
+	return v.Stats(start, end)
+}
+
+// String lays the statistics out as a table with a row per day and a total.
+func (s *Stats) String() string {
+	if s == nil {
+		return "no history"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-14s %8s %8s %9s %5s %8s %5s\n", "Day", "Planned", "Meetings", "Unplanned", "Moved", "Canceled", "Total")
+	for _, d := range s.Days {
+		fmt.Fprintf(&b, "%-14s %8s %8s %9s %5d %8d %5d\n", d.Date.Format("Mon 2006-01-02"),
+			d.Planned, d.Meetings, d.Unplanned, d.Moved, d.Cancelled, d.Total)
+	}
+	fmt.Fprintf(&b, "%-14s %8s %8s %9s %5d %8d %5d", "Total",
+		s.Planned, s.Meetings, s.Unplanned, s.Moved, s.Cancelled, s.Total)
+	return b.String()
+}
@@ -0,0 +1,55 @@
+package vault
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWeekStats(t *testing.T) {
+	v := testVault(t)
+	stats, err := v.WeekStats(time.Date(2024, 1, 10, 9, 0, 0, 0, time.Local))
+	if err != nil {
+		t.Fatalf("WeekStats failed: %v", err)
+	}
+	if stats.Start.Format("2006-01-02") != "2024-01-08" || stats.End.Format("2006-01-02") != "2024-01-14" {
+		t.Errorf("Expected the weekly note's range, got %s to %s", stats.Start, stats.End)
+	}
+	if len(stats.Days) != 1 {
+		t.Fatalf("Expected one daily note, got %d", len(stats.Days))
+	}
+
+	// The fixture's parent tasks, the [/] task with a plain bullet under it
+	// and the [-] and [?] tasks are left out, as in the templates.
+	day := stats.Days[0]
+	checks := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"Planned", day.Planned.String(), "2/4"},
+		{"Meetings", day.Meetings.String(), "1/2"},
+		{"Unplanned", day.Unplanned.String(), "1/2"},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("Expected %s %s, got %s", c.name, c.want, c.got)
+		}
+	}
+	if day.Moved != 1 || day.Cancelled != 1 || day.Total != 8 {
+		t.Errorf("Expected 1 moved, 1 cancelled and 8 in total, got %d, %d and %d", day.Moved, day.Cancelled, day.Total)
+	}
+	if stats.Total != day.Total || stats.Planned != day.Planned {
+		t.Errorf("Expected the week to sum its days, got %+v", stats)
+	}
+	if !strings.Contains(stats.String(), "Wed 2024-01-10      2/4      1/2       1/2     1        1     8") {
+		t.Errorf("Unexpected table:\n%s", stats)
+	}
+}
+
+func TestComputeDayStats_IgnoresOtherSections(t *testing.T) {
+	d := ComputeDayStats([]byte("## Notes\n- [x] Not planned work\n### Goals\n- [i] Learned something\n- [k] Key result\n"))
+	if d.Planned.String() != "2/2" || d.Total != 2 {
+		t.Errorf("Expected only Goals to count, got %+v", d)
+	}
+}
@@ -0,0 +1,347 @@
+package vault
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// TaskStatus is the character inside a task's checkbox. The daily and weekly
+// templates give meaning to several custom statuses beyond "x".
+type TaskStatus rune
+
+const (
+	StatusOpen       TaskStatus = ' '
+	StatusDone       TaskStatus = 'x'
+	StatusImportant  TaskStatus = '!'
+	StatusFire       TaskStatus = 'f'
+	StatusScheduled  TaskStatus = '<'
+	StatusKey        TaskStatus = 'k'
+	StatusInfo       TaskStatus = 'i'
+	StatusMoved      TaskStatus = '>'
+	StatusCancelled  TaskStatus = '-'
+	StatusQuestion   TaskStatus = '?'
+	StatusInProgress TaskStatus = '/'
+)
+
+// Completed reports whether the templates count the task as done.
+func (s TaskStatus) Completed() bool {
+	switch s {
+	case StatusDone, StatusImportant, StatusFire, StatusScheduled, StatusKey, StatusInfo:
+		return true
+	}
+	return false
+}
+
+func (s TaskStatus) Cancelled() bool {
+	return s == StatusCancelled
+}
+
+func (s TaskStatus) Moved() bool {
+	return s == StatusMoved
+}
+
+// Counted reports whether the templates include the task in their totals;
+// cancelled, question and in-progress tasks are left out.
+func (s TaskStatus) Counted() bool {
+	switch s {
+	case StatusCancelled, StatusQuestion, StatusInProgress:
+		return false
+	}
+	return true
+}
+
+// Open reports whether the task still needs doing.
+func (s TaskStatus) Open() bool {
+	return !s.Completed() && !s.Cancelled() && !s.Moved()
+}
+
+func (s TaskStatus) String() string {
+	return string(rune(s))
+}
+
+func (s TaskStatus) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *TaskStatus) UnmarshalText(text []byte) error {
+	r := []rune(string(text))
+	if len(r) != 1 {
+		return fmt.Errorf("invalid task status %q", text)
+	}
+	*s = TaskStatus(r[0])
+	return nil
+}
+
+// Task is a list item from a note. Plain bullets are kept (with IsTask unset)
+// because Dataview counts them as children, and the templates only count
+// tasks without children. Fields holds the item's inline fields, such as
+// fire:: or review::.
+type Task struct {
+	Text     string     `json:"text"`
+	Status   TaskStatus `json:"status,omitempty"`
+	IsTask   bool       `json:"isTask"`
+	Section  string     `json:"section"`
+	Line     int        `json:"-"`
+	Fields   Fields     `json:"fields,omitempty"`
+	Children []*Task    `json:"children,omitempty"`
+}
+
+// Leaf reports whether the item has no nested list items.
+func (t *Task) Leaf() bool {
+	return len(t.Children) == 0
+}
+
+func (t *Task) String() string {
+	var b strings.Builder
+	if t.IsTask {
+		fmt.Fprintf(&b, "[%s] ", t.Status)
+	}
+	b.WriteString(t.Text)
+	if t.Section != "" {
+		fmt.Fprintf(&b, " (%s)", t.Section)
+	}
+	if len(t.Children) > 0 {
+		children := make([]string, len(t.Children))
+		for i, c := range t.Children {
+			children[i] = c.String()
+		}
+		fmt.Fprintf(&b, " {%s}", strings.Join(children, "; "))
+	}
+	return b.String()
+}
+
+// ParseTasks returns the top-level list items of a note with their nested
+// items attached. Each item records the heading it sits under, which is what
+// Dataview exposes as meta(r.header).subpath.
+func ParseTasks(content []byte) []*Task {
+	type level struct {
+		width int
+		item  *Task
+	}
+	var roots []*Task
+	var stack []level
+	section := ""
+
+	walkLines(content, frontmatterEnd(content), func(l mdLine) {
+		if l.code {
+			return
+		}
+		if l.indent < 4 {
+			if _, text, ok := parseHeading(l.trimmed); ok {
+				section = text
+				stack = nil
+				return
+			}
+		}
+		width, text, ok := listItem(l.text)
+		if !ok {
+			// A paragraph that is not indented ends the list.
+			if strings.TrimSpace(l.text) != "" && width == 0 {
+				stack = nil
+			}
+			return
+		}
+
+		item := &Task{Text: text, Section: section, Line: l.number}
+		if status, text, ok := parseTaskLine(l.text); ok {
+			item.Text = text
+			item.Status = TaskStatus(status)
+			item.IsTask = true
+		}
+		item.Fields = ParseInlineFields(item.Text)
+		for len(stack) > 0 && stack[len(stack)-1].width >= width {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			roots = append(roots, item)
+		} else {
+			parent := stack[len(stack)-1].item
+			parent.Children = append(parent.Children, item)
+		}
+		stack = append(stack, level{width: width, item: item})
+	})
+	return roots
+}
+
+// flattenTasks returns every task in items and their children, depth first,
+// skipping plain list items.
+func flattenTasks(items []*Task) []*Task {
+	var tasks []*Task
+	for _, item := range items {
+		if item.IsTask {
+			tasks = append(tasks, item)
+		}
+		tasks = append(tasks, flattenTasks(item.Children)...)
+	}
+	return tasks
+}
+
+// listItem reports the indentation width of a bullet or numbered list item
+// and its text. For other lines it returns the indentation width only.
+func listItem(line string) (int, string, bool) {
+	width := 0
+	i := 0
+	for ; i < len(line); i++ {
+		if line[i] == ' ' {
+			width++
+		} else if line[i] == '\t' {
+			width += 4
+		} else {
+			break
+		}
+	}
+	rest := line[i:]
+	if len(rest) >= 2 && strings.ContainsRune("-*+", rune(rest[0])) && rest[1] == ' ' {
+		return width, strings.TrimSpace(rest[2:]), true
+	}
+	digits := 0
+	for digits < len(rest) && rest[digits] >= '0' && rest[digits] <= '9' {
+		digits++
+	}
+	if digits > 0 && digits+1 < len(rest) && (rest[digits] == '.' || rest[digits] == ')') && rest[digits+1] == ' ' {
+		return width, strings.TrimSpace(rest[digits+2:]), true
+	}
+	return width, "", false
+}
+
+// parseTaskLine splits a Markdown task such as "- [x] Ship it" into its
+// checkbox status and text. "[X]" is read as "[x]", as Obsidian renders both
+// as checked.
+func parseTaskLine(line string) (rune, string, bool) {
+	_, rest, ok := listItem(strings.TrimRight(line, "\r"))
+	if !ok || len(rest) < 3 || rest[0] != '[' {
+		return 0, "", false
+	}
+	closing := strings.IndexByte(rest, ']')
+	if closing < 0 {
+		return 0, "", false
+	}
+	status := []rune(rest[1:closing])
+	if len(status) != 1 {
+		return 0, "", false
+	}
+	if status[0] == 'X' {
+		status[0] = rune(StatusDone)
+	}
+	return status[0], strings.TrimSpace(rest[closing+1:]), true
+}
+
+// MergeTasks returns body with items merged in. It only adds and reorders:
+// top-level list items whose text is in items move into the order of items,
+// each kept verbatim with everything nested under it, and the rest of items
+// are added as open tasks. Everything else in body, such as items the plan
+// leaves out, stays after the item it followed. Items are matched ignoring
+// case, inline fields and block ids, so a task keeps its status, fields and
+// subtasks when re-planned.
+func MergeTasks(body string, items []string) string {
+	wanted := make(map[string]bool)
+	for _, item := range items {
+		wanted[taskKey(item)] = true
+	}
+
+	// Each matched item carries the lines after it up to the next match;
+	// head holds those before the first.
+	type run struct {
+		item      []string
+		following []string
+	}
+	runs := make(map[string]*run)
+	var head []string
+	tail := &head
+	var block []string
+	key := ""
+	root := -1
+	trim := func(lines []string) []string {
+		for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+			lines = lines[:len(lines)-1]
+		}
+		return lines
+	}
+	flush := func() {
+		item := trim(block)
+		blank := block[len(item):]
+		if _, seen := runs[key]; root >= 0 && wanted[key] && !seen {
+			r := &run{item: item}
+			runs[key] = r
+			tail = &r.following
+		} else {
+			*tail = append(*tail, item...)
+		}
+		*tail = append(*tail, blank...)
+		block, key, root = nil, "", -1
+	}
+	walkLines([]byte(body), 0, func(l mdLine) {
+		width, text, ok := listItem(l.text)
+		switch {
+		case ok && !l.code && (root < 0 || width <= root):
+			flush()
+			if _, task, ok := parseTaskLine(l.text); ok {
+				text = task
+			}
+			key, root = taskKey(text), width
+		case root >= 0 && (width > root || strings.TrimSpace(l.text) == ""):
+		default:
+			flush()
+			*tail = append(*tail, l.text)
+			return
+		}
+		block = append(block, l.text)
+	})
+	flush()
+
+	lines := trim(head)
+	added := make(map[string]bool)
+	for _, item := range items {
+		k := taskKey(item)
+		if k == "" || added[k] {
+			continue
+		}
+		added[k] = true
+		if r, ok := runs[k]; ok {
+			lines = append(lines, r.item...)
+			lines = append(lines, trim(r.following)...)
+		} else {
+			lines = append(lines, "- [ ] "+strings.TrimSpace(item))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// blockID matches an Obsidian block reference at the end of a line.
+var blockID = regexp.MustCompile(`\s\^[A-Za-z0-9-]+$`)
+
+// taskKey is what MergeTasks compares tasks by.
+func taskKey(text string) string {
+	text = blockID.ReplaceAllString(strings.TrimSpace(text), "")
+	return strings.ToLower(StripInlineFields(text))
+}
+
+// Tasks returns the top-level list items in the managed sections.
+func (n *DailyNote) Tasks() []*Task {
+	var tasks []*Task
+	for _, t := range ParseTasks(n.Bytes()) {
+		for _, s := range ManagedSections {
+			if t.Section == string(s) {
+				tasks = append(tasks, t)
+				break
+			}
+		}
+	}
+	return tasks
+}
+
+// FindTask returns the first open task in the managed sections whose text
+// contains query, ignoring case.
+func (n *DailyNote) FindTask(query string) *Task {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return nil
+	}
+	for _, t := range flattenTasks(n.Tasks()) {
+		if t.Status.Open() && strings.Contains(strings.ToLower(t.Text), query) {
+			return t
+		}
+	}
+	return nil
+}
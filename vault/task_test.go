@@ -0,0 +1,161 @@
+package vault
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseTaskLine(t *testing.T) {
+	tests := []struct {
+		line   string
+		status rune
+		text   string
+		ok     bool
+	}{
+		{"- [ ] Open task", ' ', "Open task", true},
+		{"\t* [x] Done task\r", 'x', "Done task", true},
+		{"- [X] Checked", 'x', "Checked", true},
+		{"- [>] Moved", '>', "Moved", true},
+		{"1. [k] Numbered", 'k', "Numbered", true},
+		{"- plain bullet", 0, "", false},
+		{"[ ] not a list item", 0, "", false},
+	}
+	for _, tt := range tests {
+		status, text, ok := parseTaskLine(tt.line)
+		if status != tt.status || text != tt.text || ok != tt.ok {
+			t.Errorf("parseTaskLine(%q) = (%q, %q, %v), expected (%q, %q, %v)", tt.line, status, text, ok, tt.status, tt.text, tt.ok)
+		}
+	}
+}
+
+func TestTaskStatus(t *testing.T) {
+	for _, s := range []TaskStatus{'x', '!', 'f', '<', 'k', 'i'} {
+		if !s.Completed() || s.Open() || !s.Counted() {
+			t.Errorf("Expected %q to be completed and counted", s)
+		}
+	}
+	for _, s := range []TaskStatus{'-', '?', '/'} {
+		if s.Counted() {
+			t.Errorf("Expected %q not to be counted", s)
+		}
+	}
+	if !StatusMoved.Moved() || StatusMoved.Open() {
+		t.Error("Expected > to be moved and not open")
+	}
+	if !StatusCancelled.Cancelled() || StatusCancelled.Open() {
+		t.Error("Expected - to be cancelled and not open")
+	}
+	if !StatusOpen.Open() || !StatusInProgress.Open() || !StatusQuestion.Open() {
+		t.Error("Expected open, in progress and question tasks to be open")
+	}
+}
+
+func TestDailyNote_Tasks(t *testing.T) {
+	v := testVault(t)
+	note, err := v.ReadDailyNote(filepath.Join(v.Root, "Journal", "Daily", "2024-01-10.md"))
+	if err != nil {
+		t.Fatalf("ReadDailyNote failed: %v", err)
+	}
+	tasks := note.Tasks()
+
+	counts := map[string]int{}
+	for _, task := range tasks {
+		counts[task.Section]++
+	}
+	if counts["Goals"] != 5 || counts["Meetings"] != 2 || counts["Bonus Items"] != 3 {
+		t.Errorf("Unexpected tasks per section: %v", counts)
+	}
+
+	editor := tasks[1]
+	if editor.Text != "Write the section editor" || editor.Status != StatusOpen {
+		t.Fatalf("Unexpected second task: %+v", editor)
+	}
+	if len(editor.Children) != 2 || editor.Leaf() {
+		t.Fatalf("Expected two children, got %d", len(editor.Children))
+	}
+	if editor.Children[0].Status != StatusDone || editor.Children[1].Text != "Fuzz tests" {
+		t.Errorf("Unexpected children: %v", editor.Children)
+	}
+
+	review := tasks[4]
+	if review.Status != StatusInProgress || len(review.Children) != 1 || review.Children[0].IsTask {
+		t.Errorf("Expected in-progress task with a plain bullet child, got %v", review)
+	}
+
+	content, err := os.ReadFile(note.Path)
+	if err != nil {
+		t.Fatalf("Failed to read note: %v", err)
+	}
+	for _, task := range flattenTasks(tasks) {
+		if _, text, _ := parseTaskLine(lineAt(content, task.Line)); text != task.Text {
+			t.Errorf("Line %d does not hold task %q", task.Line, task.Text)
+		}
+	}
+}
+
+func TestParseTasks_IgnoresCodeAndCallouts(t *testing.T) {
+	content := []byte("## Tasks\n```dataview\n- [ ] not a task\n```\n> - [ ] quoted\n### Goals\n- [ ] real\nparagraph\n  - [ ] not nested\n")
+	tasks := ParseTasks(content)
+	if len(tasks) != 2 {
+		t.Fatalf("Expected 2 top-level tasks, got %d: %v", len(tasks), tasks)
+	}
+	if tasks[0].Text != "real" || tasks[0].Section != "Goals" || !tasks[0].Leaf() {
+		t.Errorf("Unexpected first task: %v", tasks[0])
+	}
+}
+
+func TestTask_JSON(t *testing.T) {
+	task := &Task{Text: "Ship", Status: StatusFire, IsTask: true, Section: "Goals"}
+	data, err := json.Marshal(task)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if string(data) != `{"text":"Ship","status":"f","isTask":true,"section":"Goals"}` {
+		t.Errorf("Unexpected JSON: %s", data)
+	}
+	var decoded Task
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if decoded.Status != StatusFire {
+		t.Errorf("Expected status f, got %q", decoded.Status)
+	}
+}
+
+func lineAt(content []byte, n int) string {
+	offset := 0
+	for i := 0; i < n; i++ {
+		_, offset = nextLine(content, offset)
+	}
+	line, _ := nextLine(content, offset)
+	return line
+}
+
+func TestMergeTasks(t *testing.T) {
+	body := "- [x] Ship the adapter [fire:: 2] ^ship\n" +
+		"    - [ ] Write the tests\n" +
+		"- [ ] Left out\n" +
+		"\tnested under left out\n" +
+		"\n" +
+		"Notes stay.\n" +
+		"- [/] Review PR"
+
+	got := MergeTasks(body, []string{"review pr", "Ship the adapter", "New task", "new task"})
+	want := "- [/] Review PR\n" +
+		"- [x] Ship the adapter [fire:: 2] ^ship\n" +
+		"    - [ ] Write the tests\n" +
+		"- [ ] Left out\n" +
+		"\tnested under left out\n" +
+		"\n" +
+		"Notes stay.\n" +
+		"- [ ] New task"
+	if got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	if got := MergeTasks("- [ ] Kept\n    - child", nil); got != "- [ ] Kept\n    - child" {
+		t.Errorf("Expected an empty plan to keep the tasks, got %q", got)
+	}
+}
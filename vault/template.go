@@ -0,0 +1,166 @@
+package vault
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// corePlaceholder matches core Templates variables such as {{date}},
+	// {{time:HH:mm}} and {{title}}.
+	corePlaceholder = regexp.MustCompile(`\{\{\s*(date|time|title)\s*(?::([^}]*))?\}\}`)
+	// templaterCommand matches Templater interpolation tags. Execution tags
+	// (<%* ... %>) are deliberately not matched because we cannot run them.
+	templaterCommand = regexp.MustCompile(`<%[-_]?\s*([^*%][^%]*?)\s*[-_]?%>`)
+	templaterCall    = regexp.MustCompile(`^(tp\.[\w.]+)\s*(?:\((.*)\))?$`)
+)
+
+// renderTemplate resolves the placeholders Obsidian's core Templates plugin
+// and the common Templater date/title commands would fill in. The note date
+// stands in for "now" so a note created late still reads as that day's note.
+// Anything it does not understand is left untouched.
+func renderTemplate(content string, date time.Time, title string, settings TemplateSettings) string {
+	content = corePlaceholder.ReplaceAllStringFunc(content, func(match string) string {
+		parts := corePlaceholder.FindStringSubmatch(match)
+		format := strings.TrimSpace(parts[2])
+		switch parts[1] {
+		case "title":
+			return title
+		case "date":
+			if format == "" {
+				format = settings.DateFormat
+			}
+		case "time":
+			if format == "" {
+				format = settings.TimeFormat
+			}
+		}
+		return formatMoment(date, format)
+	})
+
+	return templaterCommand.ReplaceAllStringFunc(content, func(match string) string {
+		command := templaterCommand.FindStringSubmatch(match)[1]
+		if value, ok := evalTemplater(command, date, title); ok {
+			return value
+		}
+		return match
+	})
+}
+
+func evalTemplater(command string, date time.Time, title string) (string, bool) {
+	call := templaterCall.FindStringSubmatch(strings.TrimSpace(command))
+	if call == nil {
+		return "", false
+	}
+	args := splitArgs(call[2])
+	format := defaultDailyFormat
+	if len(args) > 0 && args[0] != "" {
+		format = unquote(args[0])
+	}
+
+	switch call[1] {
+	case "tp.file.title":
+		return title, true
+	case "tp.date.now", "tp.file.creation_date":
+		offset := 0
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil {
+				return "", false
+			}
+			offset = n
+		}
+		return formatMoment(date.AddDate(0, 0, offset), format), true
+	case "tp.date.yesterday":
+		return formatMoment(date.AddDate(0, 0, -1), format), true
+	case "tp.date.tomorrow":
+		return formatMoment(date.AddDate(0, 0, 1), format), true
+	}
+	return "", false
+}
+
+// splitArgs splits a JavaScript argument list on commas outside of quotes.
+func splitArgs(s string) []string {
+	var args []string
+	var quote rune
+	start := 0
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'' || r == '`':
+			quote = r
+		case r == ',':
+			args = append(args, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if strings.TrimSpace(s) != "" {
+		args = append(args, strings.TrimSpace(s[start:]))
+	}
+	return args
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && strings.ContainsRune("\"'`", rune(s[0])) && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// setFrontmatterField sets a top-level frontmatter key, replacing an existing
+// value or adding the key before the closing ---. A frontmatter block is
+// created when the note has none.
+func setFrontmatterField(content []byte, key, value string) []byte {
+	line := key + ": " + value
+	end := frontmatterEnd(content)
+	if end == 0 {
+		return append([]byte("---\n"+line+"\n---\n"), content...)
+	}
+
+	offset := bytes.IndexByte(content, '\n') + 1
+	for offset < end {
+		raw, next := nextLine(content, offset)
+		trimmed := strings.TrimRight(raw, "\r")
+		if trimmed == "---" {
+			break
+		}
+		if strings.HasPrefix(trimmed, key+":") {
+			// Drop any indented continuation lines belonging to the old value.
+			stop := next
+			for stop < end {
+				cont, after := nextLine(content, stop)
+				if cont == "" || (cont[0] != ' ' && cont[0] != '\t') {
+					break
+				}
+				stop = after
+			}
+			newline := "\n"
+			if strings.HasSuffix(raw, "\r") {
+				newline = "\r\n"
+			}
+			return concat(content[:offset], []byte(line+newline), content[stop:])
+		}
+		offset = next
+	}
+
+	closing := bytes.LastIndex(content[:end-1], []byte("\n")) + 1
+	newline := "\n"
+	if bytes.HasPrefix(content, []byte("---\r\n")) {
+		newline = "\r\n"
+	}
+	return concat(content[:closing], []byte(line+newline), content[closing:])
+}
+
+func concat(parts ...[]byte) []byte {
+	var b bytes.Buffer
+	for _, p := range parts {
+		b.Write(p)
+	}
+	return b.Bytes()
+}
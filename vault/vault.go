@@ -0,0 +1,70 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ErrNoteNotFound is returned when no note in the vault matches a lookup.
+var ErrNoteNotFound = errors.New("note not found")
+
+// Vault is an Obsidian vault on disk. All paths handed out by the vault are
+// absolute so callers never have to know where the vault lives. Writes are
+// recorded in Journal when it is set.
+type Vault struct {
+	Root    string
+	Journal *Journal
+
+	// written maps each note the planner wrote to the hash of what it
+	// wrote, so watchers can tell its own saves from the user's.
+	written sync.Map
+}
+
+func New(root string) (*Vault, error) {
+	if root == "" {
+		return nil, errors.New("vault path is not configured")
+	}
+	if strings.HasPrefix(root, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		root = filepath.Join(home, root[2:])
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("vault path %s is not a directory", root)
+	}
+	return &Vault{Root: root}, nil
+}
+
+// walkNotes calls fn for every Markdown file in the vault, skipping hidden
+// folders such as .obsidian and .trash.
+func (v *Vault) walkNotes(fn func(path string) error) error {
+	return filepath.WalkDir(v.Root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != v.Root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".md" {
+			return nil
+		}
+		return fn(path)
+	})
+}
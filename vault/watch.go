@@ -0,0 +1,108 @@
+package vault
+
+import (
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce folds the burst of events one save produces into a single
+// change.
+const watchDebounce = 200 * time.Millisecond
+
+// Watcher reports changes to a fixed set of notes on Changes. It watches the
+// folders holding the notes rather than the files themselves, because
+// Obsidian and the planner both save by replacing the file, which ends a
+// watch on the old one. The planner's own saves through the vault are not
+// reported.
+type Watcher struct {
+	Changes <-chan string
+	Errors  <-chan error
+
+	vault   *Vault
+	watcher *fsnotify.Watcher
+	done    chan struct{}
+	once    sync.Once
+}
+
+// Watch starts watching paths. Callers must Close the watcher.
+func (v *Vault) Watch(paths ...string) (*Watcher, error) {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	watched := make(map[string]bool)
+	for _, p := range paths {
+		p = filepath.Clean(p)
+		if watched[p] {
+			continue
+		}
+		watched[p] = true
+		if err := fw.Add(filepath.Dir(p)); err != nil {
+			fw.Close()
+			return nil, err
+		}
+	}
+
+	changes := make(chan string)
+	errs := make(chan error)
+	w := &Watcher{Changes: changes, Errors: errs, vault: v, watcher: fw, done: make(chan struct{})}
+	go w.run(watched, changes, errs)
+	return w, nil
+}
+
+func (w *Watcher) run(watched map[string]bool, changes chan<- string, errs chan<- error) {
+	defer close(changes)
+	pending := make(map[string]bool)
+	timer := time.NewTimer(watchDebounce)
+	timer.Stop()
+	for {
+		select {
+		case <-w.done:
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			path := filepath.Clean(event.Name)
+			if !watched[path] || event.Op == fsnotify.Chmod {
+				continue
+			}
+			pending[path] = true
+			timer.Reset(watchDebounce)
+		case <-timer.C:
+			for path := range pending {
+				if w.vault.wroteLast(path) {
+					continue
+				}
+				select {
+				case changes <- path:
+				case <-w.done:
+					return
+				}
+			}
+			clear(pending)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			select {
+			case errs <- err:
+			case <-w.done:
+				return
+			}
+		}
+	}
+}
+
+// Close stops the watcher and closes Changes.
+func (w *Watcher) Close() error {
+	var err error
+	w.once.Do(func() {
+		close(w.done)
+		err = w.watcher.Close()
+	})
+	return err
+}
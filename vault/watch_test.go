@@ -0,0 +1,61 @@
+package vault
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	v := writeFiles(t, map[string]string{
+		"Daily/today.md": "today\n",
+		"Daily/other.md": "other\n",
+	})
+	today := filepath.Join(v.Root, "Daily", "today.md")
+	w, err := v.Watch(today)
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	defer w.Close()
+
+	// Unwatched notes in the same folder are ignored.
+	if err := os.WriteFile(filepath.Join(v.Root, "Daily", "other.md"), []byte("edited\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// The planner's own saves are not reported.
+	if err := v.writeNote(today, []byte("planned\n")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case path := <-w.Changes:
+		t.Errorf("Expected the planner's save to be skipped, got a change to %s", path)
+	case <-time.After(3 * watchDebounce):
+	}
+	// Saving by replacing the file, as Obsidian does, is still seen.
+	if err := atomicWrite(today, []byte("edited\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case path := <-w.Changes:
+		if path != today {
+			t.Errorf("Expected change to %s, got %s", today, path)
+		}
+	case err := <-w.Errors:
+		t.Fatalf("Watcher error: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for a change")
+	}
+
+	select {
+	case path := <-w.Changes:
+		t.Errorf("Expected one change per save, got another for %s", path)
+	case <-time.After(3 * watchDebounce):
+	}
+
+	w.Close()
+	if _, ok := <-w.Changes; ok {
+		t.Error("Expected Changes to be closed")
+	}
+}
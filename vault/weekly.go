@@ -0,0 +1,60 @@
+package vault
+
+import (
+	"errors"
+	"time"
+)
+
+const weeklyGoalsHeading = "Weekly Goals"
+
+// WeeklyNote finds the weekly note whose journal-date/journal-end-date range
+// covers date. These are the same frontmatter fields the weekly template's
+// dataview queries rely on.
+func (v *Vault) WeeklyNote(date time.Time) (*Note, error) {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local)
+	var found *Note
+	errFound := errors.New("found")
+	err := v.walkNotes(func(path string) error {
+		note, err := ReadNote(path)
+		if err != nil {
+			// A note with broken frontmatter is not ours to fix, keep looking.
+			return nil
+		}
+		start, ok := note.Date("journal-date")
+		if !ok {
+			return nil
+		}
+		end, ok := note.Date("journal-end-date")
+		if !ok {
+			return nil
+		}
+		if day.Before(start) || day.After(end) {
+			return nil
+		}
+		found = note
+		return errFound
+	})
+	if err != nil && !errors.Is(err, errFound) {
+		return nil, err
+	}
+	if found == nil {
+		return nil, ErrNoteNotFound
+	}
+	return found, nil
+}
+
+// WeeklyGoals returns the open tasks listed under "## Weekly Goals" in the
+// weekly note covering date.
+func (v *Vault) WeeklyGoals(date time.Time) ([]string, error) {
+	note, err := v.WeeklyNote(date)
+	if err != nil {
+		return nil, err
+	}
+	var goals []string
+	for _, t := range flattenTasks(ParseTasks(note.Content)) {
+		if t.Section == weeklyGoalsHeading && t.Status.Open() && t.Text != "" {
+			goals = append(goals, t.Text)
+		}
+	}
+	return goals, nil
+}
@@ -0,0 +1,69 @@
+package vault
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testVault(t *testing.T) *Vault {
+	t.Helper()
+	v, err := New(filepath.Join("testdata", "vault"))
+	if err != nil {
+		t.Fatalf("Failed to open fixture vault: %v", err)
+	}
+	return v
+}
+
+func TestNew_MissingPath(t *testing.T) {
+	if _, err := New(""); err == nil {
+		t.Error("Expected error for empty vault path, got nil")
+	}
+	if _, err := New(filepath.Join("testdata", "does-not-exist")); err == nil {
+		t.Error("Expected error for missing vault path, got nil")
+	}
+}
+
+func TestWeeklyNote(t *testing.T) {
+	v := testVault(t)
+
+	tests := []struct {
+		date time.Time
+		want string
+	}{
+		{time.Date(2024, 1, 8, 9, 0, 0, 0, time.Local), "2024-W02.md"},
+		{time.Date(2024, 1, 14, 23, 0, 0, 0, time.Local), "2024-W02.md"},
+		{time.Date(2024, 1, 15, 0, 0, 0, 0, time.Local), "2024-W03.md"},
+	}
+	for _, tt := range tests {
+		note, err := v.WeeklyNote(tt.date)
+		if err != nil {
+			t.Fatalf("WeeklyNote(%s) failed: %v", tt.date.Format("2006-01-02"), err)
+		}
+		if filepath.Base(note.Path) != tt.want {
+			t.Errorf("Expected %s for %s, got %s", tt.want, tt.date.Format("2006-01-02"), filepath.Base(note.Path))
+		}
+	}
+}
+
+func TestWeeklyNote_NotFound(t *testing.T) {
+	v := testVault(t)
+	_, err := v.WeeklyNote(time.Date(2023, 6, 1, 0, 0, 0, 0, time.Local))
+	if !errors.Is(err, ErrNoteNotFound) {
+		t.Errorf("Expected ErrNoteNotFound, got %v", err)
+	}
+}
+
+func TestWeeklyGoals(t *testing.T) {
+	v := testVault(t)
+	goals, err := v.WeeklyGoals(time.Date(2024, 1, 10, 0, 0, 0, 0, time.Local))
+	if err != nil {
+		t.Fatalf("WeeklyGoals failed: %v", err)
+	}
+	want := []string{"Ship the planner vault adapter", "Improve test coverage 10%", "Calendar package"}
+	if !reflect.DeepEqual(goals, want) {
+		t.Errorf("Expected goals %v, got %v", want, goals)
+	}
+}
@@ -0,0 +1,93 @@
+package vault
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// noteWrite is the new content of one note.
+type noteWrite struct {
+	path    string
+	content []byte
+}
+
+// writeNote replaces the note at path atomically so Obsidian never sees a
+// half-written file, recording the write in the journal when there is one.
+func (v *Vault) writeNote(path string, content []byte) error {
+	return v.writeNotes(noteWrite{path, content})
+}
+
+// writeNotes writes notes in order as one change, which the journal undoes
+// as a whole.
+func (v *Vault) writeNotes(writes ...noteWrite) error {
+	operation := newJournalID()
+	for _, w := range writes {
+		var before []byte
+		if v.Journal != nil {
+			prev, err := os.ReadFile(w.path)
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			if err == nil && prev == nil {
+				// An empty note still has a previous version to go back to.
+				prev = []byte{}
+			}
+			before = prev
+		}
+		// Journal the write first, so no note changes without a way back.
+		var entry JournalEntry
+		if v.Journal != nil {
+			var err error
+			if entry, err = v.Journal.record(operation, w.path, before, w.content); err != nil {
+				return err
+			}
+		}
+		if err := atomicWrite(w.path, w.content); err != nil {
+			if v.Journal != nil {
+				return errors.Join(err, v.Journal.forget(entry))
+			}
+			return err
+		}
+		v.written.Store(filepath.Clean(w.path), hashContent(w.content))
+	}
+	return nil
+}
+
+// wroteLast reports whether the note at path holds what the planner last
+// wrote to it.
+func (v *Vault) wroteLast(path string) bool {
+	hash, ok := v.written.Load(path)
+	if !ok {
+		return false
+	}
+	content, err := os.ReadFile(path)
+	return err == nil && hashContent(content) == hash
+}
+
+func atomicWrite(path string, content []byte) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}